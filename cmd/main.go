@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -12,8 +13,13 @@ import (
 	"time"
 
 	"git.woa.com/lideding/gin-tai-login/internal/config"
+	"git.woa.com/lideding/gin-tai-login/internal/discovery"
+	"git.woa.com/lideding/gin-tai-login/internal/httpclient"
+	"git.woa.com/lideding/gin-tai-login/internal/lifecycle"
+	"git.woa.com/lideding/gin-tai-login/internal/logging"
 	"git.woa.com/lideding/gin-tai-login/internal/middleware"
 	"git.woa.com/lideding/gin-tai-login/internal/router"
+	"git.woa.com/lideding/gin-tai-login/internal/session"
 	"github.com/gin-gonic/gin"
 )
 
@@ -24,19 +30,59 @@ func main() {
 		log.Fatalf("配置加载失败: %v", err)
 	}
 
-	// 2. 设置 Gin 运行模式
+	// 2. 初始化日志输出（标准输出 / 滚动文件 / syslog）
+	logs, err := logging.Setup(cfg.Log)
+	if err != nil {
+		log.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logs.Close()
+	log.SetOutput(logs.App)
+	gin.DefaultWriter = logs.Access
+	gin.DefaultErrorWriter = logs.App
+
+	// 3. 设置 Gin 运行模式
 	gin.SetMode(cfg.Server.Mode)
 
-	// 3. 创建 OIDC 中间件
-	oidcMiddleware, err := middleware.NewOIDCMiddleware(cfg.OIDC)
+	// 4. 创建会话存储与 OIDC 中间件（通过统一的出站客户端访问 Provider）
+	//    登录会话、refresh token 与 API key 共用同一存储，以 key 前缀区分
+	sessionStore, err := session.NewStore(context.Background(), cfg.Session)
+	if err != nil {
+		log.Fatalf("会话存储初始化失败: %v", err)
+	}
+	// Redis 存储持有连接池，服务器排空连接后再关闭
+	if closer, ok := sessionStore.(io.Closer); ok {
+		defer closer.Close()
+	}
+	httpClient := httpclient.New(cfg.HTTPClient)
+	oidcMiddleware, err := middleware.NewOIDCMiddleware(cfg.OIDC, httpClient, session.Prefixed(sessionStore, "session:"))
 	if err != nil {
 		log.Fatalf("OIDC 中间件初始化失败: %v", err)
 	}
 
-	// 4. 设置路由
-	r := router.SetupRouter(oidcMiddleware)
+	// 5. 创建 JWT 中间件（可选）
+	var jwtMiddleware *middleware.JWTMiddleware
+	if cfg.JWT.Enabled() {
+		jwtMiddleware, err = middleware.NewJWTMiddleware(cfg.JWT, session.Prefixed(sessionStore, "refresh:"))
+		if err != nil {
+			log.Fatalf("JWT 中间件初始化失败: %v", err)
+		}
+	}
+
+	// 6. 创建 API key 中间件（可选）
+	var apiKeyMiddleware *middleware.APIKeyMiddleware
+	if cfg.Admin.APIKeysEnabled {
+		if cfg.Session.Backend != session.BackendRedis {
+			log.Printf("⚠️  API key 保存在进程内存中，重启后丢失；生产环境请设置 SESSION_STORE=redis")
+		}
+		apiKeyMiddleware = middleware.NewAPIKeyMiddleware(session.Prefixed(sessionStore, "apikey:"))
+	}
+
+	// 7. 设置路由
+	lc := lifecycle.New()
+	lc.SetReadOnly(cfg.Server.ReadOnly)
+	r := router.SetupRouter(cfg, oidcMiddleware, jwtMiddleware, apiKeyMiddleware, lc)
 
-	// 5. 输出启动信息
+	// 8. 输出启动信息
 	addr := fmt.Sprintf("0.0.0.0:%s", cfg.Server.Port)
 	log.Println("========================================")
 	log.Println("Server starting on :" + cfg.Server.Port)
@@ -45,13 +91,14 @@ func main() {
 	log.Println("  - Client ID:", cfg.OIDC.ClientID)
 	log.Println("  - Redirect URL:", cfg.OIDC.RedirectURL)
 	log.Println("  - Scopes:", strings.Join(cfg.OIDC.Scopes, ", "))
+	log.Println("  - Session Store:", cfg.Session.Backend)
 	log.Println("========================================")
 	log.Println("")
 	log.Println("⚠️  请确保在 TAI 后台配置的回调地址为:")
 	log.Println("   ", cfg.OIDC.RedirectURL)
 	log.Println("")
 
-	// 6. 启动 HTTP 服务器（支持优雅关机）
+	// 9. 启动 HTTP 服务器（支持优雅关机）
 	srv := &http.Server{
 		Addr:    addr,
 		Handler: r,
@@ -63,13 +110,45 @@ func main() {
 		}
 	}()
 
-	// 7. 等待中断信号，优雅关机
+	// 10. 注册到 Consul（可选）
+	var registrar *discovery.ConsulRegistrar
+	if cfg.Discovery.Enabled() {
+		registrar, err = discovery.NewConsulRegistrar(cfg.Discovery, cfg.Server.Port, httpClient)
+		if err != nil {
+			log.Fatalf("服务注册初始化失败: %v", err)
+		}
+		if err := registrar.Register(context.Background()); err != nil {
+			log.Fatalf("服务注册失败: %v", err)
+		}
+		log.Println("已注册到 Consul:", registrar.ServiceID())
+	}
+
+	// 11. 等待中断信号或 /admin/quitquitquit，优雅关机
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	select {
+	case <-quit:
+	case <-lc.ShutdownRequested():
+	}
 	log.Println("正在关闭服务器...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// 立即将 /readyz 置为未就绪，让负载均衡停止转发新流量
+	lc.BeginShutdown()
+
+	// 先注销，避免关机期间仍被发现
+	if registrar != nil {
+		if err := registrar.Deregister(context.Background()); err != nil {
+			log.Printf("服务注销失败: %v", err)
+		}
+	}
+
+	// 等待负载均衡感知未就绪状态后再排空连接
+	if cfg.Server.ShutdownDelay > 0 {
+		log.Printf("等待 %s 后开始排空连接...", cfg.Server.ShutdownDelay)
+		time.Sleep(cfg.Server.ShutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
@@ -3,37 +3,142 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"git.woa.com/lideding/gin-tai-login/internal/discovery"
+	"git.woa.com/lideding/gin-tai-login/internal/httpclient"
+	"git.woa.com/lideding/gin-tai-login/internal/logging"
 	"git.woa.com/lideding/gin-tai-login/internal/middleware"
+	"git.woa.com/lideding/gin-tai-login/internal/session"
 )
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port string // 监听端口
-	Mode string // Gin 运行模式（debug/release/test）
+	Port            string        // 监听端口
+	Mode            string        // Gin 运行模式（debug/release/test）
+	RequestTimeout  time.Duration // 单个请求的最长处理时间，同时作为上游截止时间的上限
+	ShutdownDelay   time.Duration // 收到关机信号后、开始排空连接前的等待时间
+	ShutdownTimeout time.Duration // 排空连接的最长时间
+	DebugEndpoints  bool          // 是否开放 /debug/echo、/debug/noop
+	ReadOnly        bool          // 启动时是否处于只读模式
+}
+
+// AdminConfig 管理接口配置
+type AdminConfig struct {
+	Token          string // 管理接口认证 token，为空则不开放管理接口
+	LoadgenEnabled bool   // 是否开放 /admin/loadgen 合成流量压测
+	APIKeysEnabled bool   // 是否启用 API key 认证及 /admin/api-keys 管理接口
 }
 
 // Config 应用配置
 type Config struct {
-	Server ServerConfig
-	OIDC   middleware.OIDCConfig
+	Server     ServerConfig
+	Admin      AdminConfig
+	OIDC       middleware.OIDCConfig
+	JWT        middleware.JWTConfig // 应用自签 JWT，未配置密钥时不启用
+	HTTPClient httpclient.Config    // 出站 HTTP 客户端（访问 OIDC Provider 等）
+	Log        logging.Config       // 访问日志与应用日志输出
+	Discovery  discovery.Config     // Consul 服务注册
+	Session    session.Config       // OIDC 登录会话存储
 }
 
 // LoadConfig 从环境变量加载配置，校验必需项
 func LoadConfig() (*Config, error) {
+	requestTimeout, err := getDuration("REQUEST_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownDelay, err := getDuration("SHUTDOWN_DELAY", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTimeout, err := getDuration("SHUTDOWN_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	// 非正的有效期会签发已过期的 token，或导致每次签发 refresh token 都失败
+	jwtExpiry, err := getDuration("JWT_EXPIRY", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if jwtExpiry <= 0 {
+		return nil, fmt.Errorf("配置项 JWT_EXPIRY 必须大于 0")
+	}
+
+	jwtRefreshExpiry, err := getDuration("JWT_REFRESH_EXPIRY", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if jwtRefreshExpiry <= 0 {
+		return nil, fmt.Errorf("配置项 JWT_REFRESH_EXPIRY 必须大于 0")
+	}
+
+	httpClientCfg, err := loadHTTPClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	logCfg, err := loadLogConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	discoveryCfg, err := loadDiscoveryConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionCfg, err := loadSessionConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionTTL, err := getDuration("SESSION_TTL", 0)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Mode: getEnv("GIN_MODE", "debug"),
+			Port:            getEnv("PORT", "8080"),
+			Mode:            getEnv("GIN_MODE", "debug"),
+			RequestTimeout:  requestTimeout,
+			ShutdownDelay:   shutdownDelay,
+			ShutdownTimeout: shutdownTimeout,
+			DebugEndpoints:  getEnv("DEBUG_ENDPOINTS_ENABLED", "false") == "true",
+			ReadOnly:        getEnv("READ_ONLY", "false") == "true",
+		},
+		Admin: AdminConfig{
+			Token:          getEnv("ADMIN_TOKEN", ""),
+			LoadgenEnabled: getEnv("ADMIN_LOADGEN_ENABLED", "false") == "true",
+			APIKeysEnabled: getEnv("API_KEYS_ENABLED", "false") == "true",
 		},
 		OIDC: middleware.OIDCConfig{
-			IssuerURL:    getEnv("OIDC_ISSUER_URL", ""),
-			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
-			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
-			RedirectURL:  getEnv("OIDC_REDIRECT_URL", fmt.Sprintf("http://127.0.0.1:%s/auth/callback", getEnv("PORT", "8080"))),
-			Scopes:       getScopes(getEnv("OIDC_SCOPES", "openid,profile")),
+			IssuerURL:      getEnv("OIDC_ISSUER_URL", ""),
+			ClientID:       getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret:   getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:    getEnv("OIDC_REDIRECT_URL", fmt.Sprintf("http://127.0.0.1:%s/auth/callback", getEnv("PORT", "8080"))),
+			Scopes:         getScopes(getEnv("OIDC_SCOPES", "openid,profile")),
+			RolesClaim:     getEnv("OIDC_ROLES_CLAIM", "roles"),
+			SessionTTL:     sessionTTL,
+			SessionSliding: getEnv("SESSION_SLIDING", "false") == "true",
 		},
+		JWT: middleware.JWTConfig{
+			Secret:         getEnv("JWT_SECRET", ""),
+			PrivateKeyFile: getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			Issuer:         getEnv("JWT_ISSUER", "gin-tai-login"),
+			Expiry:         jwtExpiry,
+			RefreshExpiry:  jwtRefreshExpiry,
+		},
+		HTTPClient: httpClientCfg,
+		Log:        logCfg,
+		Discovery:  discoveryCfg,
+		Session:    sessionCfg,
 	}
 
 	// 校验必需的 OIDC 配置项
@@ -51,6 +156,105 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("缺少必需的配置项: %s", strings.Join(missing, ", "))
 	}
 
+	// API key 只能通过管理接口创建，没有 ADMIN_TOKEN 时启用毫无意义
+	if cfg.Admin.APIKeysEnabled && cfg.Admin.Token == "" {
+		return nil, fmt.Errorf("API_KEYS_ENABLED 需要同时配置 ADMIN_TOKEN")
+	}
+
+	return cfg, nil
+}
+
+// loadHTTPClientConfig 加载出站 HTTP 客户端配置，未设置的项使用 httpclient.DefaultConfig
+func loadHTTPClientConfig() (httpclient.Config, error) {
+	cfg := httpclient.DefaultConfig()
+
+	var err error
+	if cfg.Timeout, err = getDuration("HTTP_CLIENT_TIMEOUT", cfg.Timeout); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxRetries, err = getInt("HTTP_CLIENT_MAX_RETRIES", cfg.MaxRetries); err != nil {
+		return cfg, err
+	}
+	if cfg.BreakerThreshold, err = getInt("HTTP_CLIENT_BREAKER_THRESHOLD", cfg.BreakerThreshold); err != nil {
+		return cfg, err
+	}
+	if cfg.BreakerCooldown, err = getDuration("HTTP_CLIENT_BREAKER_COOLDOWN", cfg.BreakerCooldown); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// loadLogConfig 加载日志输出配置，默认仅输出到标准输出
+func loadLogConfig() (logging.Config, error) {
+	cfg := logging.Config{
+		Stdout:     getEnv("LOG_STDOUT", "true") == "true",
+		AccessFile: getEnv("LOG_ACCESS_FILE", ""),
+		AppFile:    getEnv("LOG_APP_FILE", ""),
+		SyslogAddr: getEnv("LOG_SYSLOG_ADDR", ""),
+	}
+
+	var err error
+	if cfg.MaxSizeMB, err = getInt("LOG_MAX_SIZE_MB", 100); err != nil {
+		return cfg, err
+	}
+	if cfg.Interval, err = getDuration("LOG_ROTATE_INTERVAL", 24*time.Hour); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxBackups, err = getInt("LOG_MAX_BACKUPS", 7); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// loadDiscoveryConfig 加载 Consul 服务注册配置，未设置 CONSUL_ADDR 时不注册
+func loadDiscoveryConfig() (discovery.Config, error) {
+	address := getEnv("SERVICE_ADDRESS", "")
+	if address == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return discovery.Config{}, fmt.Errorf("获取主机名失败: %w", err)
+		}
+		address = hostname
+	}
+
+	cfg := discovery.Config{
+		ConsulAddr:  getEnv("CONSUL_ADDR", ""),
+		Token:       getEnv("CONSUL_TOKEN", ""),
+		ServiceName: getEnv("SERVICE_NAME", "gin-tai-login"),
+		ServiceID:   getEnv("SERVICE_ID", ""),
+		Address:     address,
+		Version:     getEnv("SERVICE_VERSION", "dev"),
+		HealthPath:  "/readyz",
+	}
+	if tags := getEnv("SERVICE_TAGS", ""); tags != "" {
+		cfg.Tags = strings.Split(tags, ",")
+	}
+
+	var err error
+	if cfg.CheckInterval, err = getDuration("CONSUL_CHECK_INTERVAL", 10*time.Second); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// loadSessionConfig 加载会话存储配置，默认使用进程内存
+func loadSessionConfig() (session.Config, error) {
+	cfg := session.Config{
+		Backend:       getEnv("SESSION_STORE", session.BackendMemory),
+		RedisAddr:     getEnv("REDIS_ADDR", "127.0.0.1:6379"),
+		RedisUsername: getEnv("REDIS_USERNAME", ""),
+		RedisPassword: getEnv("REDIS_PASSWORD", ""),
+		RedisTLS:      getEnv("REDIS_TLS", "false") == "true",
+		KeyPrefix:     getEnv("REDIS_KEY_PREFIX", "gin-tai-login:"),
+	}
+
+	var err error
+	if cfg.RedisDB, err = getInt("REDIS_DB", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.DialTimeout, err = getDuration("REDIS_DIAL_TIMEOUT", 5*time.Second); err != nil {
+		return cfg, err
+	}
 	return cfg, nil
 }
 
@@ -63,6 +267,32 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getDuration 获取时长类型的环境变量（如 "30s"），不存在时返回默认值
+func getDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("配置项 %s 格式错误: %w", key, err)
+	}
+	return d, nil
+}
+
+// getInt 获取整数类型的环境变量，不存在时返回默认值
+func getInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("配置项 %s 格式错误: %w", key, err)
+	}
+	return n, nil
+}
+
 // getScopes 解析 scopes 字符串为数组
 func getScopes(scopesStr string) []string {
 	if scopesStr == "" {
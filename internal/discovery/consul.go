@@ -0,0 +1,133 @@
+// Package discovery 负责将服务实例注册到 Consul，供无服务网格的环境做服务发现
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Config Consul 注册配置
+type Config struct {
+	ConsulAddr    string        // Consul agent 地址，例如 http://127.0.0.1:8500，为空则不注册
+	Token         string        // Consul ACL token
+	ServiceName   string        // 服务名
+	ServiceID     string        // 实例 ID，为空时使用 <服务名>-<地址>-<端口>
+	Address       string        // 对外通告的地址，为空时使用主机名
+	Tags          []string      // 服务标签
+	Version       string        // 服务版本，写入 Meta
+	HealthPath    string        // 健康检查路径
+	CheckInterval time.Duration // 健康检查间隔
+}
+
+// Enabled 是否启用服务注册
+func (c Config) Enabled() bool {
+	return c.ConsulAddr != ""
+}
+
+// ConsulRegistrar 通过 Consul agent HTTP API 注册/注销服务实例
+type ConsulRegistrar struct {
+	cfg       Config
+	port      int
+	client    *http.Client
+	serviceID string
+}
+
+// registration Consul /v1/agent/service/register 请求体
+type registration struct {
+	ID      string            `json:"ID"`
+	Name    string            `json:"Name"`
+	Address string            `json:"Address"`
+	Port    int               `json:"Port"`
+	Tags    []string          `json:"Tags,omitempty"`
+	Meta    map[string]string `json:"Meta,omitempty"`
+	Check   *check            `json:"Check,omitempty"`
+}
+
+type check struct {
+	HTTP                           string `json:"HTTP"`
+	Interval                       string `json:"Interval"`
+	Timeout                        string `json:"Timeout"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter"`
+}
+
+// NewConsulRegistrar 创建 Consul 注册器，port 为 HTTP 服务监听端口
+func NewConsulRegistrar(cfg Config, port string, client *http.Client) (*ConsulRegistrar, error) {
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service port %q: %w", port, err)
+	}
+
+	serviceID := cfg.ServiceID
+	if serviceID == "" {
+		serviceID = fmt.Sprintf("%s-%s-%d", cfg.ServiceName, cfg.Address, p)
+	}
+
+	return &ConsulRegistrar{
+		cfg:       cfg,
+		port:      p,
+		client:    client,
+		serviceID: serviceID,
+	}, nil
+}
+
+// Register 注册服务实例及 HTTP 健康检查
+func (r *ConsulRegistrar) Register(ctx context.Context) error {
+	reg := registration{
+		ID:      r.serviceID,
+		Name:    r.cfg.ServiceName,
+		Address: r.cfg.Address,
+		Port:    r.port,
+		Tags:    r.cfg.Tags,
+		Meta:    map[string]string{"version": r.cfg.Version},
+		Check: &check{
+			HTTP:                           fmt.Sprintf("http://%s:%d%s", r.cfg.Address, r.port, r.cfg.HealthPath),
+			Interval:                       r.cfg.CheckInterval.String(),
+			Timeout:                        "5s",
+			DeregisterCriticalServiceAfter: "1m",
+		},
+	}
+
+	body, err := json.Marshal(reg)
+	if err != nil {
+		return fmt.Errorf("failed to encode consul registration: %w", err)
+	}
+	return r.put(ctx, "/v1/agent/service/register", body)
+}
+
+// Deregister 注销服务实例
+func (r *ConsulRegistrar) Deregister(ctx context.Context) error {
+	return r.put(ctx, "/v1/agent/service/deregister/"+url.PathEscape(r.serviceID), nil)
+}
+
+// ServiceID 返回实例 ID
+func (r *ConsulRegistrar) ServiceID() string {
+	return r.serviceID
+}
+
+func (r *ConsulRegistrar) put(ctx context.Context, path string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, r.cfg.ConsulAddr+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build consul request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", r.cfg.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("consul request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("consul %s returned status %d", path, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"git.woa.com/lideding/gin-tai-login/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler API key 管理请求处理器
+type APIKeyHandler struct {
+	apiKeyMw *middleware.APIKeyMiddleware
+}
+
+// NewAPIKeyHandler 创建 API key Handler
+func NewAPIKeyHandler(apiKeyMw *middleware.APIKeyMiddleware) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyMw: apiKeyMw}
+}
+
+// HandleCreate 创建 API key，委托给 APIKeyMiddleware
+func (h *APIKeyHandler) HandleCreate(c *gin.Context) {
+	h.apiKeyMw.HandleCreate(c)
+}
+
+// HandleList 列出 API key，委托给 APIKeyMiddleware
+func (h *APIKeyHandler) HandleList(c *gin.Context) {
+	h.apiKeyMw.HandleList(c)
+}
+
+// HandleRevoke 吊销 API key，委托给 APIKeyMiddleware
+func (h *APIKeyHandler) HandleRevoke(c *gin.Context) {
+	h.apiKeyMw.HandleRevoke(c)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"git.woa.com/lideding/gin-tai-login/internal/authz"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthzHandler 授权矩阵审计请求处理器
+type AuthzHandler struct {
+	matrix *authz.Matrix
+	engine *gin.Engine
+}
+
+// NewAuthzHandler 创建 Authz Handler，engine 用于列出实际注册的路由
+func NewAuthzHandler(matrix *authz.Matrix, engine *gin.Engine) *AuthzHandler {
+	return &AuthzHandler{matrix: matrix, engine: engine}
+}
+
+// Matrix 展示实际注册的每条路由及其生效的授权规则，未登记的路由标记为 unlisted（请求会被拒绝）
+func (h *AuthzHandler) Matrix(c *gin.Context) {
+	routes := h.engine.Routes()
+
+	effective := make([]authz.Rule, 0, len(routes))
+	for _, route := range routes {
+		rule, ok := h.matrix.Lookup(route.Method, route.Path)
+		if !ok {
+			rule = authz.Rule{Method: route.Method, Path: route.Path, Access: "unlisted"}
+		}
+		// Any 注册的路由会命中同一条 AnyMethod 规则，按实际注册的方法逐行展示
+		rule.Method = route.Method
+		effective = append(effective, rule)
+	}
+
+	Success(c, gin.H{
+		"routes": effective,
+	})
+}
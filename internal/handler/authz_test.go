@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.woa.com/lideding/gin-tai-login/internal/authz"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthzMatrixListsAnyRoutesPerMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	matrix := authz.NewMatrix([]authz.Rule{
+		{Method: authz.AnyMethod, Path: "/debug/echo", Access: authz.AccessPublic},
+		{Method: http.MethodGet, Path: "/admin/authz", Access: authz.AccessAdminToken},
+	})
+
+	r := gin.New()
+	r.Any("/debug/echo", Echo)
+	r.GET("/admin/authz", NewAuthzHandler(matrix, r).Matrix)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/authz", nil))
+
+	var resp struct {
+		Data struct {
+			Routes []authz.Rule `json:"routes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, rule := range resp.Data.Routes {
+		if rule.Path != "/debug/echo" {
+			continue
+		}
+		if rule.Method == authz.AnyMethod || seen[rule.Method] {
+			t.Fatalf("want one row per registered method, got %+v", resp.Data.Routes)
+		}
+		if rule.Access != authz.AccessPublic {
+			t.Fatalf("%s /debug/echo: got access %q", rule.Method, rule.Access)
+		}
+		seen[rule.Method] = true
+	}
+	if !seen[http.MethodGet] || !seen[http.MethodPost] {
+		t.Fatalf("missing methods for /debug/echo: %v", seen)
+	}
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"git.woa.com/lideding/gin-tai-login/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// maxEchoBody /debug/echo 回显的请求体上限
+const maxEchoBody = 1 << 20
+
+// redactedHeaders 回显时隐藏的凭据类请求头，/debug/echo 无需认证，不能泄露会话或密钥
+var redactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	middleware.HeaderAdminToken,
+	middleware.HeaderAPIKey,
+}
+
+// Noop 不做任何业务处理，仅用于衡量中间件栈本身的开销
+func Noop(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
+// Echo 回显请求的方法、路径、查询参数、请求头与请求体，凭据类请求头以 [REDACTED] 代替
+func Echo(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxEchoBody))
+	if err != nil {
+		Error(c, http.StatusBadRequest, http.StatusBadRequest, "failed to read body")
+		return
+	}
+
+	Success(c, gin.H{
+		"method":  c.Request.Method,
+		"path":    c.Request.URL.Path,
+		"query":   c.Request.URL.Query(),
+		"headers": redactHeaders(c.Request.Header),
+		"body":    string(body),
+	})
+}
+
+// redactHeaders 复制请求头并隐藏凭据类字段的值
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range redactedHeaders {
+		if _, ok := out[http.CanonicalHeaderKey(name)]; ok {
+			out.Set(name, "[REDACTED]")
+		}
+	}
+	return out
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"git.woa.com/lideding/gin-tai-login/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// JWTHandler JWT 相关请求处理器
+type JWTHandler struct {
+	jwtMw *middleware.JWTMiddleware
+}
+
+// NewJWTHandler 创建 JWT Handler
+func NewJWTHandler(jwtMw *middleware.JWTMiddleware) *JWTHandler {
+	return &JWTHandler{jwtMw: jwtMw}
+}
+
+// HandleIssueToken 为当前 OIDC 会话签发 JWT 与 refresh token，委托给 JWTMiddleware
+func (h *JWTHandler) HandleIssueToken(c *gin.Context) {
+	h.jwtMw.HandleIssueToken(c)
+}
+
+// HandleRefresh 轮换 refresh token 并签发新 JWT，委托给 JWTMiddleware
+func (h *JWTHandler) HandleRefresh(c *gin.Context) {
+	h.jwtMw.HandleRefresh(c)
+}
+
+// HandleRevoke 吊销 refresh token，委托给 JWTMiddleware
+func (h *JWTHandler) HandleRevoke(c *gin.Context) {
+	h.jwtMw.HandleRevoke(c)
+}
+
+// HandleRevokeSubject 吊销指定用户的全部 refresh token，委托给 JWTMiddleware
+func (h *JWTHandler) HandleRevokeSubject(c *gin.Context) {
+	h.jwtMw.HandleRevokeSubject(c)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"git.woa.com/lideding/gin-tai-login/internal/lifecycle"
+	"github.com/gin-gonic/gin"
+)
+
+// LifecycleHandler 就绪探针、只读模式与关机相关请求处理器
+type LifecycleHandler struct {
+	lc *lifecycle.Lifecycle
+}
+
+// NewLifecycleHandler 创建 Lifecycle Handler
+func NewLifecycleHandler(lc *lifecycle.Lifecycle) *LifecycleHandler {
+	return &LifecycleHandler{lc: lc}
+}
+
+// Readyz 就绪探针，收到关机信号后立即返回 503，让负载均衡摘除流量
+func (h *LifecycleHandler) Readyz(c *gin.Context) {
+	if !h.lc.Ready() {
+		Error(c, http.StatusServiceUnavailable, http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+	Success(c, gin.H{
+		"status": "ready",
+	})
+}
+
+// Quit 触发优雅关机（/quitquitquit），供 preStop 钩子调用
+func (h *LifecycleHandler) Quit(c *gin.Context) {
+	h.lc.BeginShutdown()
+	Success(c, gin.H{
+		"message": "shutting down",
+	})
+}
+
+// readOnlyRequest 切换只读模式的请求参数
+type readOnlyRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// GetReadOnly 查询只读模式状态
+func (h *LifecycleHandler) GetReadOnly(c *gin.Context) {
+	Success(c, gin.H{
+		"read_only": h.lc.ReadOnly(),
+	})
+}
+
+// SetReadOnly 开启或关闭只读模式
+func (h *LifecycleHandler) SetReadOnly(c *gin.Context) {
+	var req readOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.lc.SetReadOnly(*req.Enabled)
+	Success(c, gin.H{
+		"read_only": h.lc.ReadOnly(),
+	})
+}
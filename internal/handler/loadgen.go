@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"git.woa.com/lideding/gin-tai-login/internal/loadgen"
+	"github.com/gin-gonic/gin"
+)
+
+// loadgenDeadlineMargin 压测结束后等待在途请求、汇总结果所预留的时间
+const loadgenDeadlineMargin = time.Second
+
+// LoadgenHandler 合成流量压测请求处理器
+type LoadgenHandler struct {
+	target http.Handler
+}
+
+// NewLoadgenHandler 创建 Loadgen Handler，target 为被压测的进程内路由引擎
+func NewLoadgenHandler(target http.Handler) *LoadgenHandler {
+	return &LoadgenHandler{target: target}
+}
+
+// loadgenRequest 压测请求参数
+type loadgenRequest struct {
+	Method   string            `json:"method"`
+	Path     string            `json:"path" binding:"required"`
+	Headers  map[string]string `json:"headers"`
+	Body     string            `json:"body"`
+	RPS      int               `json:"rps" binding:"required"`
+	Duration string            `json:"duration" binding:"required"` // 例如 "10s"
+}
+
+// Run 同步执行一次压测并返回延迟分位数；请求 context 取消时提前结束
+func (h *LoadgenHandler) Run(c *gin.Context) {
+	var req loadgenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		Error(c, http.StatusBadRequest, http.StatusBadRequest, "invalid duration")
+		return
+	}
+
+	spec := loadgen.Spec{
+		Method:   req.Method,
+		Path:     req.Path,
+		Headers:  req.Headers,
+		Body:     req.Body,
+		RPS:      req.RPS,
+		Duration: duration,
+	}
+	if err := spec.Validate(); err != nil {
+		Error(c, http.StatusBadRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 压测在本请求内同步执行，持续时间不能超过请求剩余的截止时间（受 REQUEST_TIMEOUT 限制），否则会被提前截断
+	if deadline, ok := c.Request.Context().Deadline(); ok {
+		if remaining := time.Until(deadline) - loadgenDeadlineMargin; duration > remaining {
+			msg := fmt.Sprintf("duration exceeds the request deadline (%s available), shorten the run or raise REQUEST_TIMEOUT", remaining.Truncate(time.Second))
+			Error(c, http.StatusBadRequest, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	Success(c, loadgen.Run(c.Request.Context(), h.target, spec))
+}
@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 目标 host 处于熔断状态
+var ErrCircuitOpen = errors.New("httpclient: circuit breaker is open")
+
+// breaker 单个 host 的熔断状态
+type breaker struct {
+	failures  int       // 连续失败次数
+	openUntil time.Time // 熔断截止时间
+	probing   bool      // 半开状态下是否已有探测请求在途
+}
+
+// breakerTransport 按 host 熔断的 RoundTripper
+type breakerTransport struct {
+	next      http.RoundTripper
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+func newBreakerTransport(next http.RoundTripper, threshold int, cooldown time.Duration) *breakerTransport {
+	return &breakerTransport{
+		next:      next,
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*breaker),
+	}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if !t.allow(host) {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	t.record(host, err == nil && resp.StatusCode < http.StatusInternalServerError)
+	return resp, err
+}
+
+// allow 判断是否放行请求；熔断期满后只放行一个探测请求
+func (t *breakerTransport) allow(host string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.breakers[host]
+	if !ok || b.failures < t.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record 记录调用结果，连续失败达到阈值后熔断
+func (t *breakerTransport) record(host string, success bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.breakers[host]
+	if !ok {
+		b = &breaker{}
+		t.breakers[host] = b
+	}
+	b.probing = false
+
+	if success {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= t.threshold {
+		b.openUntil = time.Now().Add(t.cooldown)
+	}
+}
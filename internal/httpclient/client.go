@@ -0,0 +1,65 @@
+// Package httpclient 提供统一的出站 HTTP 客户端：连接池、超时、重试预算与按 host 熔断
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"git.woa.com/lideding/gin-tai-login/internal/tracing"
+)
+
+// Config 出站 HTTP 客户端配置
+type Config struct {
+	Timeout             time.Duration // 单次调用（含重试）的总超时
+	MaxIdleConnsPerHost int           // 每个 host 保留的空闲连接数
+	MaxRetries          int           // 单个请求最多重试次数
+	RetryBackoff        time.Duration // 首次重试前的等待时间，之后指数增长
+	RetryBudgetRatio    float64       // 重试次数占请求数的最大比例
+	BreakerThreshold    int           // 连续失败多少次后熔断该 host
+	BreakerCooldown     time.Duration // 熔断后多久允许探测请求
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		Timeout:             10 * time.Second,
+		MaxIdleConnsPerHost: 10,
+		MaxRetries:          2,
+		RetryBackoff:        100 * time.Millisecond,
+		RetryBudgetRatio:    0.1,
+		BreakerThreshold:    5,
+		BreakerCooldown:     30 * time.Second,
+	}
+}
+
+// New 按配置创建出站 HTTP 客户端。
+// 调用链：注入 traceparent → 重试 → 按 host 熔断 → 连接池
+func New(cfg Config) *http.Client {
+	base := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	var rt http.RoundTripper = base
+	if cfg.BreakerThreshold > 0 {
+		rt = newBreakerTransport(rt, cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+	if cfg.MaxRetries > 0 {
+		rt = newRetryTransport(rt, cfg.MaxRetries, cfg.RetryBackoff, cfg.RetryBudgetRatio)
+	}
+
+	return &http.Client{
+		Transport: tracing.NewTransport(rt),
+		Timeout:   cfg.Timeout,
+	}
+}
@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// retryTransport 对幂等请求在网络错误或 502/503/504 时重试，
+// 并通过重试预算限制重试总量，避免故障时放大流量
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	backoff    time.Duration
+	budget     *retryBudget
+}
+
+func newRetryTransport(next http.RoundTripper, maxRetries int, backoff time.Duration, ratio float64) *retryTransport {
+	return &retryTransport{
+		next:       next,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+		budget:     newRetryBudget(ratio),
+	}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.budget.deposit()
+
+	resp, err := t.next.RoundTrip(req)
+	if !isIdempotent(req) {
+		return resp, err
+	}
+
+	wait := t.backoff
+	for attempt := 0; attempt < t.maxRetries && shouldRetry(resp, err); attempt++ {
+		if !t.budget.withdraw() {
+			break
+		}
+
+		// 请求体需能重放才可重试
+		retryReq := req
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				break
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				break
+			}
+			retryReq = req.Clone(req.Context())
+			retryReq.Body = body
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+
+		resp, err = t.next.RoundTrip(retryReq)
+	}
+
+	return resp, err
+}
+
+// isIdempotent 仅幂等方法可安全重试
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// shouldRetry 网络错误与网关类错误可重试；熔断错误不重试
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryBudget 令牌桶式重试预算：每个请求存入 ratio 个令牌，每次重试消耗 1 个
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+	max    float64
+}
+
+func newRetryBudget(ratio float64) *retryBudget {
+	// 预留少量令牌，保证低流量时也能重试
+	return &retryBudget{ratio: ratio, tokens: 10, max: 10}
+}
+
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+}
+
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,48 @@
+// Package lifecycle 管理实例的运行状态：就绪、只读与关机触发，配合 Kubernetes 的 readiness/preStop 使用
+package lifecycle
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Lifecycle 实例生命周期状态
+type Lifecycle struct {
+	ready    atomic.Bool
+	readOnly atomic.Bool
+	shutdown chan struct{}
+	once     sync.Once
+}
+
+// New 创建生命周期状态，初始为就绪
+func New() *Lifecycle {
+	l := &Lifecycle{shutdown: make(chan struct{})}
+	l.ready.Store(true)
+	return l
+}
+
+// Ready 实例是否可以接收新流量
+func (l *Lifecycle) Ready() bool {
+	return l.ready.Load()
+}
+
+// ReadOnly 实例是否处于只读模式
+func (l *Lifecycle) ReadOnly() bool {
+	return l.readOnly.Load()
+}
+
+// SetReadOnly 开启或关闭只读模式
+func (l *Lifecycle) SetReadOnly(enabled bool) {
+	l.readOnly.Store(enabled)
+}
+
+// BeginShutdown 立即将实例标记为未就绪并通知关机，可重复调用
+func (l *Lifecycle) BeginShutdown() {
+	l.ready.Store(false)
+	l.once.Do(func() { close(l.shutdown) })
+}
+
+// ShutdownRequested 收到关机请求时关闭的 channel
+func (l *Lifecycle) ShutdownRequested() <-chan struct{} {
+	return l.shutdown
+}
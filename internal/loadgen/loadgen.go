@@ -0,0 +1,214 @@
+// Package loadgen 通过进程内的 http.Handler 生成合成流量，用于在新硬件上做容量验证
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxRPS 单次压测允许的最大 RPS
+	MaxRPS = 5000
+	// MaxDuration 单次压测允许的最长时间
+	MaxDuration = time.Minute
+	// maxInFlight 同时在途的请求上限，超出时丢弃本次请求并计数
+	maxInFlight = 512
+)
+
+// syntheticKey 标记合成请求的 context key
+type syntheticKey struct{}
+
+// IsSynthetic 请求是否由 loadgen 生成。标记保存在 context 中，外部请求无法伪造
+func IsSynthetic(r *http.Request) bool {
+	v, _ := r.Context().Value(syntheticKey{}).(bool)
+	return v
+}
+
+// Spec 压测参数
+type Spec struct {
+	Method   string            // 请求方法，默认 GET
+	Path     string            // 请求路径（含查询参数）
+	Headers  map[string]string // 附加请求头，例如访问受保护路由时的 Cookie
+	Body     string            // 请求体
+	RPS      int               // 每秒请求数
+	Duration time.Duration     // 持续时间
+}
+
+// Validate 校验并补全压测参数
+func (s *Spec) Validate() error {
+	if s.Method == "" {
+		s.Method = http.MethodGet
+	}
+	if !strings.HasPrefix(s.Path, "/") {
+		return errors.New("path must start with /")
+	}
+	if isAdminPath(s.Path) {
+		// 否则携带管理 token 即可递归调用 /admin/loadgen 放大流量，或调用 /admin/quitquitquit 关停实例
+		return errors.New("path must not target /admin")
+	}
+	if s.RPS <= 0 || s.RPS > MaxRPS {
+		return fmt.Errorf("rps must be between 1 and %d", MaxRPS)
+	}
+	if s.Duration <= 0 || s.Duration > MaxDuration {
+		return fmt.Errorf("duration must be between 0 and %s", MaxDuration)
+	}
+	return nil
+}
+
+// isAdminPath 按路由实际匹配的路径（解码、清理后）判断是否落在 /admin 下；无法解析的路径同样拒绝
+func isAdminPath(p string) bool {
+	u, err := url.Parse(p)
+	if err != nil {
+		return true
+	}
+	clean := path.Clean(u.Path)
+	return clean == "/admin" || strings.HasPrefix(clean, "/admin/")
+}
+
+// Result 压测结果
+type Result struct {
+	Requests  int            `json:"requests"`
+	Dropped   int            `json:"dropped"` // 因在途请求过多而未发出的请求数
+	Status    map[int]int    `json:"status"`  // 各状态码计数
+	Duration  string         `json:"duration"`
+	ActualRPS float64        `json:"actual_rps"`
+	Latency   LatencySummary `json:"latency"`
+}
+
+// LatencySummary 延迟分位数
+type LatencySummary struct {
+	P50 string `json:"p50"`
+	P90 string `json:"p90"`
+	P99 string `json:"p99"`
+	Max string `json:"max"`
+}
+
+// Run 按 spec 匀速向 h 发送请求，直到持续时间结束或 ctx 取消
+func Run(ctx context.Context, h http.Handler, spec Spec) Result {
+	ctx, cancel := context.WithTimeout(ctx, spec.Duration)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies []time.Duration
+		result    = Result{Status: make(map[int]int)}
+		inFlight  = make(chan struct{}, maxInFlight)
+	)
+
+	ticker := time.NewTicker(time.Second / time.Duration(spec.RPS))
+	defer ticker.Stop()
+
+	start := time.Now()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			mu.Lock()
+			result.Dropped++
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+
+			status, latency := serveOnce(h, spec)
+
+			mu.Lock()
+			result.Requests++
+			result.Status[status]++
+			latencies = append(latencies, latency)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	result.Duration = elapsed.Round(time.Millisecond).String()
+	result.ActualRPS = float64(result.Requests) / elapsed.Seconds()
+	result.Latency = summarize(latencies)
+	return result
+}
+
+// serveOnce 通过进程内 handler 处理一次请求，返回状态码与耗时
+func serveOnce(h http.Handler, spec Spec) (int, time.Duration) {
+	ctx := context.WithValue(context.Background(), syntheticKey{}, true)
+	req, err := http.NewRequestWithContext(ctx, spec.Method, spec.Path, strings.NewReader(spec.Body))
+	if err != nil {
+		return 0, 0
+	}
+	for k, v := range spec.Headers {
+		req.Header.Set(k, v)
+	}
+	req.RemoteAddr = "127.0.0.1:0"
+
+	w := &discardWriter{header: make(http.Header)}
+	begin := time.Now()
+	h.ServeHTTP(w, req)
+	latency := time.Since(begin)
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.status, latency
+}
+
+// summarize 计算延迟分位数
+func summarize(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	at := func(p float64) string {
+		idx := int(float64(len(latencies)-1) * p)
+		return latencies[idx].String()
+	}
+	return LatencySummary{
+		P50: at(0.50),
+		P90: at(0.90),
+		P99: at(0.99),
+		Max: latencies[len(latencies)-1].String(),
+	}
+}
+
+// discardWriter 丢弃响应体，仅记录状态码的 http.ResponseWriter
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (w *discardWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
@@ -0,0 +1,35 @@
+package loadgen
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpecValidate(t *testing.T) {
+	tests := []struct {
+		path     string
+		rps      int
+		duration time.Duration
+		wantErr  bool
+	}{
+		{"/ping", 10, time.Second, false},
+		{"/api/ping?x=1", MaxRPS, MaxDuration, false},
+		{"/administrator", 10, time.Second, false},
+		{"ping", 10, time.Second, true},
+		{"/admin", 10, time.Second, true},
+		{"/admin/loadgen", 10, time.Second, true},
+		{"/admin/quitquitquit", 10, time.Second, true},
+		{"/api/../admin/quitquitquit", 10, time.Second, true},
+		{"/%61dmin/quitquitquit", 10, time.Second, true},
+		{"/ping", 0, time.Second, true},
+		{"/ping", MaxRPS + 1, time.Second, true},
+		{"/ping", 10, 0, true},
+		{"/ping", 10, MaxDuration + time.Second, true},
+	}
+	for _, tt := range tests {
+		spec := Spec{Path: tt.path, RPS: tt.rps, Duration: tt.duration}
+		if err := spec.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q, rps=%d, duration=%s) = %v, wantErr %v", tt.path, tt.rps, tt.duration, err, tt.wantErr)
+		}
+	}
+}
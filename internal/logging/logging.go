@@ -0,0 +1,96 @@
+// Package logging 配置访问日志与应用日志的输出：标准输出、滚动文件以及 syslog 转发
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config 日志输出配置
+type Config struct {
+	Stdout     bool          // 是否输出到标准输出
+	AccessFile string        // 访问日志文件路径，为空则不写文件
+	AppFile    string        // 应用日志文件路径，为空则不写文件
+	MaxSizeMB  int           // 单个日志文件最大大小（MB）
+	Interval   time.Duration // 按时间滚动的间隔，0 表示不按时间滚动
+	MaxBackups int           // 保留的历史文件数
+	SyslogAddr string        // syslog 转发地址，例如 udp://127.0.0.1:514，为空则不转发
+}
+
+// Outputs 初始化后的日志输出
+type Outputs struct {
+	Access io.Writer // 访问日志（Gin Logger）
+	App    io.Writer // 应用日志（标准库 log）
+
+	closers []io.Closer
+}
+
+// Setup 根据配置创建日志输出，调用方需在退出前调用 Close
+func Setup(cfg Config) (*Outputs, error) {
+	out := &Outputs{}
+
+	access, err := out.build(cfg, cfg.AccessFile, "gin-tai-login-access")
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+	app, err := out.build(cfg, cfg.AppFile, "gin-tai-login")
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+
+	out.Access = access
+	out.App = app
+	return out, nil
+}
+
+// Close 关闭所有日志文件与 syslog 连接
+func (o *Outputs) Close() error {
+	var firstErr error
+	for _, c := range o.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// build 组合单类日志的各个输出目标
+func (o *Outputs) build(cfg Config, path, tag string) (io.Writer, error) {
+	var writers []io.Writer
+	if cfg.Stdout {
+		writers = append(writers, os.Stdout)
+	}
+
+	if path != "" {
+		rf, err := OpenRotatingFile(path, int64(cfg.MaxSizeMB)<<20, cfg.Interval, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		o.closers = append(o.closers, rf)
+		writers = append(writers, rf)
+	}
+
+	if cfg.SyslogAddr != "" {
+		network, addr, ok := strings.Cut(cfg.SyslogAddr, "://")
+		if !ok {
+			return nil, fmt.Errorf("invalid syslog address %q, expected network://host:port", cfg.SyslogAddr)
+		}
+		sw, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_LOCAL0, tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		o.closers = append(o.closers, sw)
+		writers = append(writers, sw)
+	}
+
+	if len(writers) == 0 {
+		return io.Discard, nil
+	}
+	return io.MultiWriter(writers...), nil
+}
@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rotateRetryInterval 滚动失败后再次尝试的间隔
+const rotateRetryInterval = time.Minute
+
+// RotatingFile 按大小和时间滚动的日志文件，滚动后的文件名为 <path>.<时间戳>
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64         // 单个文件最大字节数，<= 0 表示不按大小滚动
+	interval   time.Duration // 按时间滚动的间隔，<= 0 表示不按时间滚动
+	maxBackups int           // 保留的历史文件数，<= 0 表示全部保留
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	retryAt  time.Time // 滚动失败后，在此之前不再尝试
+}
+
+// OpenRotatingFile 打开（或创建）日志文件并追加写入
+func OpenRotatingFile(path string, maxSize int64, interval time.Duration, maxBackups int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	rf := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		interval:   interval,
+		maxBackups: maxBackups,
+	}
+	f, size, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rf.file, rf.size, rf.openedAt = f, size, time.Now()
+	return rf, nil
+}
+
+// Write 实现 io.Writer，写入前按需滚动
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.shouldRotate(len(p)) {
+		if err := rf.rotate(); err != nil {
+			// 滚动失败时继续写入当前文件，稍后重试，避免丢失日志
+			fmt.Fprintf(os.Stderr, "log rotation failed, retrying in %s: %v\n", rotateRetryInterval, err)
+			rf.retryAt = time.Now().Add(rotateRetryInterval)
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Close 关闭当前日志文件
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	return rf.file.Close()
+}
+
+func (rf *RotatingFile) shouldRotate(n int) bool {
+	if time.Now().Before(rf.retryAt) {
+		return false
+	}
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(n) > rf.maxSize {
+		return true
+	}
+	return rf.interval > 0 && time.Since(rf.openedAt) >= rf.interval
+}
+
+// openLogFile 以追加模式打开日志文件，返回已有内容的大小
+func openLogFile(path string) (*os.File, int64, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("failed to stat log file: %w", err)
+	}
+	return f, info.Size(), nil
+}
+
+// rotate 将当前文件重命名为带时间戳的备份并打开新文件，然后清理多余备份。
+// 新文件打开成功后才替换 rf.file，任一步失败时仍可继续写入原文件
+func (rf *RotatingFile) rotate() error {
+	backup := rf.path + "." + time.Now().Format("20060102-150405.000")
+	if err := os.Rename(rf.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	f, size, err := openLogFile(rf.path)
+	if err != nil {
+		// 新文件打不开时把备份改回原路径，当前文件句柄仍指向它
+		os.Rename(backup, rf.path)
+		return err
+	}
+
+	old := rf.file
+	rf.file, rf.size, rf.openedAt = f, size, time.Now()
+	old.Close()
+
+	rf.prune()
+	return nil
+}
+
+// prune 删除超出 maxBackups 的最旧备份，清理失败不影响写日志
+func (rf *RotatingFile) prune() {
+	if rf.maxBackups <= 0 {
+		return
+	}
+
+	backups, err := filepath.Glob(rf.path + ".*")
+	if err != nil || len(backups) <= rf.maxBackups {
+		return
+	}
+
+	// 时间戳后缀可按字典序排序
+	sort.Strings(backups)
+	for _, old := range backups[:len(backups)-rf.maxBackups] {
+		os.Remove(old)
+	}
+}
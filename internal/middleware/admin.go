@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderAdminToken 管理接口的认证请求头
+const HeaderAdminToken = "X-Admin-Token"
+
+// RequireAdminToken 要求请求携带与配置一致的 X-Admin-Token，token 为空时拒绝所有请求
+func RequireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.GetHeader(HeaderAdminToken)
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin token"})
+			return
+		}
+		c.Next()
+	}
+}
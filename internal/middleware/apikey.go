@@ -0,0 +1,380 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"git.woa.com/lideding/gin-tai-login/internal/authz"
+	"git.woa.com/lideding/gin-tai-login/internal/session"
+)
+
+const (
+	// HeaderAPIKey API key 认证请求头
+	HeaderAPIKey = "X-API-Key"
+	// apiKeyPrefix 便于在日志、代码仓库中识别泄露的 key
+	apiKeyPrefix = "gtl_"
+	// apiKeyLockTTL 索引锁的最长持有时间，持有者异常退出后自动释放
+	apiKeyLockTTL = 5 * time.Second
+	// apiKeyUsedInterval 最近使用时间的写入间隔，避免每个认证请求都写存储
+	apiKeyUsedInterval = time.Minute
+)
+
+var (
+	// errAPIKeyNotFound API key 不存在或已吊销
+	errAPIKeyNotFound = errors.New("API key not found")
+	// errAPIKeyLocked 其他实例正在修改 API key 索引
+	errAPIKeyLocked = errors.New("API key index is locked")
+)
+
+// APIKey API key 元数据（不含 key 本身）
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Roles      []string   `json:"roles,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// apiKeyRecord 存储中的 API key 记录
+type apiKeyRecord struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+// APIKeyMiddleware 机器客户端的 API key 认证中间件。key 仅以哈希形式保存在会话存储中
+// （Redis 时多实例共享、重启不丢失），key 布局：
+//
+//	key:<hash>   key 哈希 -> ID，认证时只需一次读取
+//	id:<id>      元数据与哈希
+//	used:<id>    最近使用时间（精度 apiKeyUsedInterval），与元数据分开写入，避免认证请求覆盖吊销
+//	index        全部 ID，供列表使用；修改时持有 lock
+type APIKeyMiddleware struct {
+	mu    sync.Mutex // 串行化本实例的索引修改，跨实例由 lock 保证
+	store session.Store
+
+	usedMu sync.Mutex
+	usedAt map[string]time.Time // 本实例上次写入 used:<id> 的时间
+}
+
+// NewAPIKeyMiddleware 创建 API key 中间件，key 保存在 store 中
+func NewAPIKeyMiddleware(store session.Store) *APIKeyMiddleware {
+	return &APIKeyMiddleware{store: store, usedAt: make(map[string]time.Time)}
+}
+
+// Create 创建 API key，返回明文 key（仅此一次）与元数据
+func (am *APIKeyMiddleware) Create(ctx context.Context, name string, roles []string) (string, APIKey, error) {
+	key := apiKeyPrefix + generateRandomState()
+	rec := apiKeyRecord{
+		APIKey: APIKey{
+			ID:        randomID(),
+			Name:      name,
+			Roles:     roles,
+			CreatedAt: time.Now(),
+		},
+		Hash: hashToken(key),
+	}
+
+	err := am.updateIndex(ctx, func(ids []string) ([]string, error) {
+		if err := am.put(ctx, "id:"+rec.ID, rec); err != nil {
+			return nil, err
+		}
+		if err := am.store.Set(ctx, "key:"+rec.Hash, []byte(rec.ID), 0); err != nil {
+			return nil, err
+		}
+		return append(ids, rec.ID), nil
+	})
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	return key, rec.APIKey, nil
+}
+
+// Revoke 吊销 API key，key 不存在时返回 errAPIKeyNotFound
+func (am *APIKeyMiddleware) Revoke(ctx context.Context, id string) error {
+	return am.updateIndex(ctx, func(ids []string) ([]string, error) {
+		var rec apiKeyRecord
+		if err := am.get(ctx, "id:"+id, &rec); err != nil {
+			return nil, err
+		}
+		// 先删除 key -> ID 映射，之后 key 立即不可用
+		for _, k := range []string{"key:" + rec.Hash, "id:" + id, "used:" + id} {
+			if err := am.store.Delete(ctx, k); err != nil {
+				return nil, err
+			}
+		}
+
+		am.usedMu.Lock()
+		delete(am.usedAt, id)
+		am.usedMu.Unlock()
+
+		kept := ids[:0]
+		for _, v := range ids {
+			if v != id {
+				kept = append(kept, v)
+			}
+		}
+		return kept, nil
+	})
+}
+
+// List 按创建时间列出全部 API key
+func (am *APIKeyMiddleware) List(ctx context.Context) ([]APIKey, error) {
+	ids, err := am.loadIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]APIKey, 0, len(ids))
+	for _, id := range ids {
+		client, err := am.load(ctx, id)
+		if errors.Is(err, errAPIKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, client)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// resolve 将 key 解析为客户端身份，并记录最近使用时间
+func (am *APIKeyMiddleware) resolve(ctx context.Context, key string) (APIKey, error) {
+	id, err := am.store.Get(ctx, "key:"+hashToken(key))
+	if errors.Is(err, session.ErrNotFound) {
+		return APIKey{}, errAPIKeyNotFound
+	}
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	client, err := am.load(ctx, string(id))
+	if err != nil {
+		return APIKey{}, err
+	}
+	// 最近使用时间仅供展示，写入失败不影响认证
+	if err := am.touch(ctx, client.ID); err != nil {
+		log.Printf("记录 API key 使用时间失败: %v", err)
+	}
+	return client, nil
+}
+
+// touch 记录最近使用时间，同一实例每个 key 每 apiKeyUsedInterval 最多写一次
+func (am *APIKeyMiddleware) touch(ctx context.Context, id string) error {
+	now := time.Now()
+	am.usedMu.Lock()
+	if now.Sub(am.usedAt[id]) < apiKeyUsedInterval {
+		am.usedMu.Unlock()
+		return nil
+	}
+	am.usedAt[id] = now
+	am.usedMu.Unlock()
+
+	if err := am.put(ctx, "used:"+id, now); err != nil {
+		return err
+	}
+	// 与 Revoke 并发时，写入可能发生在吊销删除 used:<id> 之后；key 已吊销则删除刚写入的记录，避免遗留
+	if _, err := am.store.Get(ctx, "id:"+id); errors.Is(err, session.ErrNotFound) {
+		return am.store.Delete(ctx, "used:"+id)
+	}
+	return nil
+}
+
+// load 读取 API key 元数据及最近使用时间
+func (am *APIKeyMiddleware) load(ctx context.Context, id string) (APIKey, error) {
+	var rec apiKeyRecord
+	if err := am.get(ctx, "id:"+id, &rec); err != nil {
+		return APIKey{}, err
+	}
+
+	var usedAt time.Time
+	err := am.get(ctx, "used:"+id, &usedAt)
+	if err == nil {
+		rec.LastUsedAt = &usedAt
+	} else if !errors.Is(err, errAPIKeyNotFound) {
+		return APIKey{}, err
+	}
+	return rec.APIKey, nil
+}
+
+// updateIndex 在索引锁内读取、修改并写回 ID 索引
+func (am *APIKeyMiddleware) updateIndex(ctx context.Context, fn func(ids []string) ([]string, error)) error {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	if err := am.lock(ctx); err != nil {
+		return err
+	}
+	defer am.store.Delete(context.WithoutCancel(ctx), "lock")
+
+	ids, err := am.loadIndex(ctx)
+	if err != nil {
+		return err
+	}
+	ids, err = fn(ids)
+	if err != nil {
+		return err
+	}
+	return am.put(ctx, "index", ids)
+}
+
+// lock 获取跨实例的索引锁，短暂重试后仍被占用时返回 errAPIKeyLocked
+func (am *APIKeyMiddleware) lock(ctx context.Context) error {
+	for i := 0; i < 20; i++ {
+		ok, err := am.store.SetNX(ctx, "lock", []byte("1"), apiKeyLockTTL)
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	return errAPIKeyLocked
+}
+
+// loadIndex 读取全部 API key ID，索引不存在时返回空列表
+func (am *APIKeyMiddleware) loadIndex(ctx context.Context) ([]string, error) {
+	var ids []string
+	if err := am.get(ctx, "index", &ids); err != nil && !errors.Is(err, errAPIKeyNotFound) {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// get 读取并反序列化记录，记录不存在时返回 errAPIKeyNotFound
+func (am *APIKeyMiddleware) get(ctx context.Context, id string, v interface{}) error {
+	data, err := am.store.Get(ctx, id)
+	if errors.Is(err, session.ErrNotFound) {
+		return errAPIKeyNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// put 序列化并写入不过期的记录
+func (am *APIKeyMiddleware) put(ctx context.Context, id string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return am.store.Set(ctx, id, data, 0)
+}
+
+// RequireAPIKeyOr 请求携带 X-API-Key 时按 API key 认证，否则交给 fallback（例如 RequireJWT）；
+// fallback 为 nil 时要求必须携带 API key
+func (am *APIKeyMiddleware) RequireAPIKeyOr(fallback gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderAPIKey)
+		if key == "" {
+			if fallback != nil {
+				fallback(c)
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing API key"})
+			return
+		}
+
+		client, err := am.resolve(c.Request.Context(), key)
+		if errors.Is(err, errAPIKeyNotFound) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			return
+		}
+		if err != nil {
+			log.Printf("读取 API key 失败: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "API key store unavailable"})
+			return
+		}
+
+		// 与 OIDC 会话、JWT 保持一致的上下文字段
+		sub := "apikey:" + client.ID
+		c.Set("user_id", sub)
+		c.Set("client_id", client.ID)
+		c.Set("user_info", map[string]interface{}{
+			"sub":      sub,
+			"username": client.Name,
+			"name":     client.Name,
+		})
+		c.Set("roles", client.Roles)
+		c.Next()
+	}
+}
+
+// createAPIKeyRequest 创建 API key 请求参数，roles 至少包含一个内置角色，/api 要求至少 viewer
+type createAPIKeyRequest struct {
+	Name  string   `json:"name" binding:"required"`
+	Roles []string `json:"roles" binding:"required,min=1"`
+}
+
+// HandleCreate 创建 API key（管理接口），明文 key 只在响应中出现一次
+func (am *APIKeyMiddleware) HandleCreate(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and at least one role are required"})
+		return
+	}
+	for _, role := range req.Roles {
+		if !authz.IsBuiltinRole(role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role " + role})
+			return
+		}
+	}
+
+	key, meta, err := am.Create(c.Request.Context(), req.Name, req.Roles)
+	if err != nil {
+		log.Printf("创建 API key 失败: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "API key store unavailable"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"key":     key,
+		"api_key": meta,
+	})
+}
+
+// HandleList 列出 API key 元数据（管理接口）
+func (am *APIKeyMiddleware) HandleList(c *gin.Context) {
+	keys, err := am.List(c.Request.Context())
+	if err != nil {
+		log.Printf("列出 API key 失败: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "API key store unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// HandleRevoke 吊销 API key（管理接口）
+func (am *APIKeyMiddleware) HandleRevoke(c *gin.Context) {
+	err := am.Revoke(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, errAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("吊销 API key 失败: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "API key store unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+// randomID 生成 API key 的公开 ID
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"git.woa.com/lideding/gin-tai-login/internal/session"
+	"github.com/gin-gonic/gin"
+)
+
+// serveWithAPIKey 携带 X-API-Key 请求受 RequireAPIKeyOr 保护的路由
+func serveWithAPIKey(am *APIKeyMiddleware, key string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ping", am.RequireAPIKeyOr(nil), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(HeaderAPIKey, key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyPersistedInStore(t *testing.T) {
+	ctx := context.Background()
+	store := session.NewMemoryStore()
+
+	key, meta, err := NewAPIKeyMiddleware(store).Create(ctx, "ci", []string{"viewer"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// 共用同一存储的另一个实例（或重启后的进程）能认证、列出并吊销该 key
+	other := NewAPIKeyMiddleware(store)
+	if w := serveWithAPIKey(other, key); w.Code != http.StatusOK || w.Body.String() != "apikey:"+meta.ID {
+		t.Fatalf("authenticate: got %d %q", w.Code, w.Body.String())
+	}
+
+	keys, err := other.List(ctx)
+	if err != nil || len(keys) != 1 || keys[0].ID != meta.ID || keys[0].LastUsedAt == nil {
+		t.Fatalf("List: got %+v, %v", keys, err)
+	}
+
+	if err := other.Revoke(ctx, meta.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if w := serveWithAPIKey(other, key); w.Code != http.StatusUnauthorized {
+		t.Fatalf("authenticate after revoke: got %d", w.Code)
+	}
+	if err := other.Revoke(ctx, meta.ID); !errors.Is(err, errAPIKeyNotFound) {
+		t.Fatalf("Revoke twice: want errAPIKeyNotFound, got %v", err)
+	}
+	if keys, _ := other.List(ctx); len(keys) != 0 {
+		t.Fatalf("List after revoke: got %+v", keys)
+	}
+}
+
+func TestAPIKeyUnknownKeyRejected(t *testing.T) {
+	am := NewAPIKeyMiddleware(session.NewMemoryStore())
+	if w := serveWithAPIKey(am, apiKeyPrefix+"unknown"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown key: got %d", w.Code)
+	}
+}
+
+func TestAPIKeyLastUsedThrottledAndNotResurrected(t *testing.T) {
+	ctx := context.Background()
+	store := session.NewMemoryStore()
+	am := NewAPIKeyMiddleware(store)
+
+	key, meta, err := am.Create(ctx, "ci", []string{"viewer"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	serveWithAPIKey(am, key)
+	if _, err := store.Get(ctx, "used:"+meta.ID); err != nil {
+		t.Fatalf("used record not written on first use: %v", err)
+	}
+
+	// 间隔内的请求不再写存储
+	store.Delete(ctx, "used:"+meta.ID)
+	serveWithAPIKey(am, key)
+	if _, err := store.Get(ctx, "used:"+meta.ID); !errors.Is(err, session.ErrNotFound) {
+		t.Fatalf("used record rewritten within apiKeyUsedInterval: %v", err)
+	}
+
+	// 模拟与吊销并发的请求：吊销后才写入的 used:<id> 不应遗留
+	if err := am.Revoke(ctx, meta.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if err := am.touch(ctx, meta.ID); err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+	if _, err := store.Get(ctx, "used:"+meta.ID); !errors.Is(err, session.ErrNotFound) {
+		t.Fatalf("used record left behind after revoke: %v", err)
+	}
+}
+
+func TestAPIKeyHandleCreateValidatesRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	am := NewAPIKeyMiddleware(session.NewMemoryStore())
+	r := gin.New()
+	r.POST("/api-keys", am.HandleCreate)
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"name":"ci","roles":["viewer"]}`, http.StatusCreated},
+		{`{"name":"ci","roles":["viewer","admin"]}`, http.StatusCreated},
+		{`{"name":"ci"}`, http.StatusBadRequest},
+		{`{"name":"ci","roles":[]}`, http.StatusBadRequest},
+		{`{"name":"ci","roles":["superuser"]}`, http.StatusBadRequest},
+		{`{"roles":["viewer"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(tt.body)))
+		if w.Code != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.body, w.Code, tt.want)
+		}
+	}
+}
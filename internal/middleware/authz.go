@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"git.woa.com/lideding/gin-tai-login/internal/authz"
+	"github.com/gin-gonic/gin"
+)
+
+// DenyUnlisted 引擎级兜底：未在授权矩阵中登记的路由一律拒绝，
+// 直接注册在引擎上、未经过任何 Authorize 路由组的路由也不例外。未匹配任何路由的请求照常返回 404
+func DenyUnlisted(m *authz.Matrix) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			c.Next()
+			return
+		}
+		if _, ok := m.Lookup(c.Request.Method, path); !ok {
+			log.Printf("⚠️  拒绝未登记路由 %s %s", c.Request.Method, path)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Route not authorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Authorize 按授权矩阵校验当前路由，access 为所在路由组的访问级别，需放在该组的认证中间件之后。
+// 未登记的路由、登记的访问级别与路由组不一致的路由一律拒绝，之后再校验所需角色
+func Authorize(m *authz.Matrix, access string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, ok := m.Lookup(c.Request.Method, c.FullPath())
+		if !ok || rule.Access != access {
+			log.Printf("⚠️  拒绝未授权路由 %s %s（所在路由组: %s）", c.Request.Method, c.FullPath(), access)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Route not authorized"})
+			return
+		}
+
+		roles := c.GetStringSlice("roles")
+		if !rule.Allows(roles) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.woa.com/lideding/gin-tai-login/internal/authz"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDenyUnlisted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	matrix := authz.NewMatrix([]authz.Rule{
+		{Method: http.MethodGet, Path: "/listed", Access: authz.AccessPublic},
+	})
+
+	// 直接注册在引擎上的路由，不经过任何 Authorize 路由组
+	r := gin.New()
+	r.Use(DenyUnlisted(matrix))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/listed", ok)
+	r.GET("/unlisted", ok)
+	r.POST("/listed", ok)
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/listed", http.StatusOK},
+		{http.MethodGet, "/unlisted", http.StatusForbidden},
+		{http.MethodPost, "/listed", http.StatusForbidden},
+		{http.MethodGet, "/missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderRequestDeadline 上游传入的绝对截止时间（RFC3339 格式）
+	HeaderRequestDeadline = "X-Request-Deadline"
+	// HeaderGRPCTimeout gRPC 风格的相对超时，例如 "500m"、"2S"
+	HeaderGRPCTimeout = "Grpc-Timeout"
+)
+
+// RequestDeadline 根据请求头推导请求 context 的截止时间，并以 maxTimeout 作为上限。
+// maxTimeout <= 0 时不设上限，仅使用请求头中的截止时间。
+func RequestDeadline(maxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+
+		var deadline time.Time
+		if maxTimeout > 0 {
+			deadline = now.Add(maxTimeout)
+		}
+
+		// 上游的截止时间更早时以上游为准
+		if upstream, ok := parseUpstreamDeadline(c.Request, now); ok {
+			if deadline.IsZero() || upstream.Before(deadline) {
+				deadline = upstream
+			}
+		}
+
+		if deadline.IsZero() {
+			c.Next()
+			return
+		}
+
+		if !deadline.After(now) {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "Request deadline exceeded"})
+			return
+		}
+
+		ctx, cancel := context.WithDeadline(c.Request.Context(), deadline)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// parseUpstreamDeadline 解析 X-Request-Deadline 或 Grpc-Timeout 请求头，无法解析时忽略
+func parseUpstreamDeadline(r *http.Request, now time.Time) (time.Time, bool) {
+	if v := r.Header.Get(HeaderRequestDeadline); v != "" {
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+	}
+
+	if v := r.Header.Get(HeaderGRPCTimeout); v != "" {
+		if d, ok := parseGRPCTimeout(v); ok {
+			return now.Add(d), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// parseGRPCTimeout 解析 gRPC 超时格式：最多 8 位数字加单位（H/M/S/m/u/n）。
+// 超出 time.Duration 范围的值（例如 99999999H）按最大值处理，随后由 maxTimeout 截断
+func parseGRPCTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, false
+	}
+
+	// 只接受纯数字，ParseInt 会接受的 "+"、"-" 前缀不属于 gRPC 格式
+	digits := v[:len(v)-1]
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	var unit time.Duration
+	switch v[len(v)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, false
+	}
+
+	if n > math.MaxInt64/int64(unit) {
+		return time.Duration(math.MaxInt64), true
+	}
+	return time.Duration(n) * unit, true
+}
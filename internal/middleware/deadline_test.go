@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"500m", 500 * time.Millisecond, true},
+		{"2S", 2 * time.Second, true},
+		{"3M", 3 * time.Minute, true},
+		{"1H", time.Hour, true},
+		{"10u", 10 * time.Microsecond, true},
+		{"7n", 7 * time.Nanosecond, true},
+		{"0S", 0, true},
+		{"99999999n", 99999999 * time.Nanosecond, true},
+		// 合法但超出 time.Duration 范围的值按最大值处理，而不是溢出为负数
+		{"3000000H", time.Duration(math.MaxInt64), true},
+		{"99999999H", time.Duration(math.MaxInt64), true},
+		{"99999999M", 99999999 * time.Minute, true},
+		{"", 0, false},
+		{"S", 0, false},
+		{"5", 0, false},
+		{"5s", 0, false},
+		{"+5S", 0, false},
+		{"-5S", 0, false},
+		{" 5S", 0, false},
+		{"1.5S", 0, false},
+		{"123456789S", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseGRPCTimeout(tt.in)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("parseGRPCTimeout(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseUpstreamDeadline(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    time.Time
+		wantOK  bool
+	}{
+		{"none", nil, time.Time{}, false},
+		{"rfc3339", map[string]string{HeaderRequestDeadline: "2026-01-02T03:04:06.5Z"}, now.Add(1500 * time.Millisecond), true},
+		{"grpc", map[string]string{HeaderGRPCTimeout: "250m"}, now.Add(250 * time.Millisecond), true},
+		{"rfc3339 wins", map[string]string{HeaderRequestDeadline: "2026-01-02T03:04:06Z", HeaderGRPCTimeout: "1H"}, now.Add(time.Second), true},
+		{"bad rfc3339 falls back to grpc", map[string]string{HeaderRequestDeadline: "soon", HeaderGRPCTimeout: "2S"}, now.Add(2 * time.Second), true},
+		{"bad grpc", map[string]string{HeaderGRPCTimeout: "+2S"}, time.Time{}, false},
+		{"huge grpc", map[string]string{HeaderGRPCTimeout: "99999999H"}, now.Add(time.Duration(math.MaxInt64)), true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		got, ok := parseUpstreamDeadline(r, now)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("%s: got %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRequestDeadlineHugeGRPCTimeoutIsCapped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestDeadline(time.Minute))
+	r.GET("/", func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		if !ok || time.Until(deadline) > time.Minute {
+			t.Errorf("deadline %v not capped to maxTimeout", deadline)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderGRPCTimeout, "99999999H")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", w.Code)
+	}
+}
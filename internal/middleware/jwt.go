@@ -0,0 +1,278 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"git.woa.com/lideding/gin-tai-login/internal/session"
+)
+
+// minHMACSecretLen HS256 密钥最小长度（字节），与签名哈希输出长度一致（RFC 7518 3.2）
+const minHMACSecretLen = 32
+
+// JWTConfig JWT 配置，Secret 与 PrivateKeyFile 二选一
+type JWTConfig struct {
+	Secret         string        // HMAC（HS256）签名密钥
+	PrivateKeyFile string        // RSA（RS256）私钥 PEM 文件路径，优先于 Secret
+	Issuer         string        // 签发者
+	Expiry         time.Duration // token 有效期
+	RefreshExpiry  time.Duration // refresh token 链的绝对有效期，自登录起计算，轮换不延长
+}
+
+// Enabled 是否配置了签名密钥
+func (c JWTConfig) Enabled() bool {
+	return c.Secret != "" || c.PrivateKeyFile != ""
+}
+
+// JWTClaims 应用签发的 JWT 声明
+type JWTClaims struct {
+	Username string   `json:"username,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// JWTMiddleware JWT 签发与 Bearer 认证中间件
+type JWTMiddleware struct {
+	method  jwt.SigningMethod
+	signKey interface{}
+	verKey  interface{}
+	issuer  string
+	expiry  time.Duration
+	refresh *refreshStore
+}
+
+// NewJWTMiddleware 创建 JWT 中间件，refresh token 保存在 store 中
+func NewJWTMiddleware(config JWTConfig, store session.Store) (*JWTMiddleware, error) {
+	jm := &JWTMiddleware{
+		issuer:  config.Issuer,
+		expiry:  config.Expiry,
+		refresh: newRefreshStore(store, config.RefreshExpiry),
+	}
+
+	switch {
+	case config.PrivateKeyFile != "":
+		pem, err := os.ReadFile(config.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT private key: %w", err)
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWT private key: %w", err)
+		}
+		jm.method = jwt.SigningMethodRS256
+		jm.signKey = key
+		jm.verKey = &key.PublicKey
+	case config.Secret != "":
+		if len(config.Secret) < minHMACSecretLen {
+			return nil, fmt.Errorf("JWT secret must be at least %d bytes for HS256", minHMACSecretLen)
+		}
+		jm.method = jwt.SigningMethodHS256
+		jm.signKey = []byte(config.Secret)
+		jm.verKey = []byte(config.Secret)
+	default:
+		return nil, errors.New("JWT secret or private key is required")
+	}
+
+	return jm, nil
+}
+
+// Issue 为用户签发 JWT，返回 token 与过期时间
+func (jm *JWTMiddleware) Issue(userInfo map[string]interface{}, roles []string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(jm.expiry)
+
+	sub, _ := userInfo["sub"].(string)
+	username, _ := userInfo["username"].(string)
+	name, _ := userInfo["name"].(string)
+
+	claims := JWTClaims{
+		Username: username,
+		Name:     name,
+		Roles:    roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   sub,
+			Issuer:    jm.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jm.method, claims).SignedString(jm.signKey)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// Verify 校验 JWT 签名、签发者与有效期
+func (jm *JWTMiddleware) Verify(tokenString string) (*JWTClaims, error) {
+	claims := &JWTClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		// 防止算法替换攻击
+		if t.Method.Alg() != jm.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+		return jm.verKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !claims.VerifyIssuer(jm.issuer, true) {
+		return nil, errors.New("unexpected issuer")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("missing subject")
+	}
+	return claims, nil
+}
+
+// RequireJWT Gin 中间件函数，要求请求携带有效的 Bearer JWT
+func (jm *JWTMiddleware) RequireJWT() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+
+		claims, err := jm.Verify(tokenString)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+
+		// 与 OIDC 会话保持一致的上下文字段，handler 无需区分认证方式
+		c.Set("user_id", claims.Subject)
+		c.Set("user_info", map[string]interface{}{
+			"sub":      claims.Subject,
+			"username": claims.Username,
+			"name":     claims.Name,
+		})
+		c.Set("roles", claims.Roles)
+		c.Next()
+	}
+}
+
+// HandleIssueToken 为已登录的 OIDC 会话签发 JWT 与 refresh token，需放在 RequireOIDC 之后
+func (jm *JWTMiddleware) HandleIssueToken(c *gin.Context) {
+	value, exists := c.Get("oidc_session")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	oidcSession := value.(*OIDCSession)
+
+	familyID, family, err := jm.refresh.start(c.Request.Context(), oidcSession.UserInfo, oidcSession.Roles)
+	if err != nil {
+		log.Printf("创建 refresh token 链失败: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Token store unavailable"})
+		return
+	}
+	jm.respondTokens(c, familyID, family)
+}
+
+// refreshRequest refresh/revoke 请求参数
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// HandleRefresh 用 refresh token 换取新的 JWT，旧 refresh token 同时失效（轮换）
+func (jm *JWTMiddleware) HandleRefresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	familyID, family, err := jm.refresh.rotate(c.Request.Context(), req.RefreshToken)
+	switch {
+	case errors.Is(err, errRefreshTokenReused):
+		log.Printf("⚠️  检测到 refresh token 重复使用，已吊销该登录链: subject=%s family=%s", family.Subject, familyID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	case errors.Is(err, errRefreshTokenInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	case err != nil:
+		log.Printf("轮换 refresh token 失败: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Token store unavailable"})
+		return
+	}
+
+	jm.respondTokens(c, familyID, family)
+}
+
+// HandleRevoke 吊销 refresh token 及其轮换链
+func (jm *JWTMiddleware) HandleRevoke(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	// 与 RFC 7009 一致，未知 token 同样返回成功
+	if err := jm.refresh.revoke(c.Request.Context(), req.RefreshToken); err != nil {
+		log.Printf("吊销 refresh token 失败: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Token store unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
+
+// HandleRevokeSubject 吊销指定用户此前签发的全部 refresh token（管理接口）
+func (jm *JWTMiddleware) HandleRevokeSubject(c *gin.Context) {
+	if err := jm.refresh.revokeSubject(c.Request.Context(), c.Param("sub")); err != nil {
+		log.Printf("吊销用户 refresh token 失败: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Token store unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Refresh tokens revoked"})
+}
+
+// respondTokens 签发 JWT 与链上新的 refresh token 并返回
+func (jm *JWTMiddleware) respondTokens(c *gin.Context, familyID string, family *refreshFamily) {
+	token, expiresAt, err := jm.Issue(family.UserInfo, family.Roles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token"})
+		return
+	}
+
+	refreshToken, err := jm.refresh.issue(c.Request.Context(), familyID, family)
+	if errors.Is(err, errRefreshTokenInvalid) {
+		// 链在本次请求中恰好到达绝对过期时间
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+	if err != nil {
+		log.Printf("保存 refresh token 失败: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Token store unavailable"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  token,
+		"token_type":    "Bearer",
+		"expires_in":    int(time.Until(expiresAt).Seconds()),
+		"refresh_token": refreshToken,
+	})
+}
+
+// bearerToken 从 Authorization 请求头提取 Bearer token
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"git.woa.com/lideding/gin-tai-login/internal/session"
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/oauth2"
@@ -15,33 +20,41 @@ import (
 
 // OIDCMiddleware OIDC 认证中间件
 type OIDCMiddleware struct {
-	provider     *oidc.Provider
-	oauth2Config oauth2.Config
-	verifier     *oidc.IDTokenVerifier
-	sessions     map[string]*OIDCSession // 简单的内存会话存储
+	provider       *oidc.Provider
+	oauth2Config   oauth2.Config
+	verifier       *oidc.IDTokenVerifier
+	httpClient     *http.Client  // 访问 OIDC Provider 使用的出站客户端
+	sessions       session.Store // 服务端会话存储（内存或 Redis）
+	sessionTTL     time.Duration // 会话有效期，为 0 时跟随 access token 过期时间
+	sessionSliding bool          // 是否在每次访问时续期会话
+	rolesClaim     string        // 读取用户角色的 claim 路径
 }
 
-// OIDCSession 会话信息
+// OIDCSession 会话信息，序列化为 JSON 后写入会话存储
 type OIDCSession struct {
-	IDToken      string
-	AccessToken  string
-	RefreshToken string
-	UserInfo     map[string]interface{}
-	ExpiresAt    time.Time
+	IDToken      string                 `json:"id_token"`
+	AccessToken  string                 `json:"access_token"`
+	RefreshToken string                 `json:"refresh_token"`
+	UserInfo     map[string]interface{} `json:"user_info"`
+	Roles        []string               `json:"roles"`
+	ExpiresAt    time.Time              `json:"expires_at"`
 }
 
 // OIDCConfig OIDC 配置
 type OIDCConfig struct {
-	IssuerURL    string   // OIDC Provider 的 Issuer URL
-	ClientID     string   // 客户端 ID
-	ClientSecret string   // 客户端密钥
-	RedirectURL  string   // 回调地址
-	Scopes       []string // 请求的权限范围
+	IssuerURL      string        // OIDC Provider 的 Issuer URL
+	ClientID       string        // 客户端 ID
+	ClientSecret   string        // 客户端密钥
+	RedirectURL    string        // 回调地址
+	Scopes         []string      // 请求的权限范围
+	RolesClaim     string        // 用户角色所在的 claim，支持点号路径，例如 realm_access.roles
+	SessionTTL     time.Duration // 会话有效期，为 0 时跟随 access token 过期时间
+	SessionSliding bool          // 滑动过期：每次访问将会话续期至 SessionTTL，需配合 SessionTTL 使用
 }
 
-// NewOIDCMiddleware 创建新的 OIDC 中间件
-func NewOIDCMiddleware(config OIDCConfig) (*OIDCMiddleware, error) {
-	ctx := context.Background()
+// NewOIDCMiddleware 创建新的 OIDC 中间件，httpClient 用于访问 OIDC Provider，sessions 保存登录会话
+func NewOIDCMiddleware(config OIDCConfig, httpClient *http.Client, sessions session.Store) (*OIDCMiddleware, error) {
+	ctx := oidc.ClientContext(context.Background(), httpClient)
 
 	// 初始化 OIDC Provider
 	provider, err := oidc.NewProvider(ctx, config.IssuerURL)
@@ -64,10 +77,14 @@ func NewOIDCMiddleware(config OIDCConfig) (*OIDCMiddleware, error) {
 	})
 
 	return &OIDCMiddleware{
-		provider:     provider,
-		oauth2Config: oauth2Config,
-		verifier:     verifier,
-		sessions:     make(map[string]*OIDCSession),
+		provider:       provider,
+		oauth2Config:   oauth2Config,
+		verifier:       verifier,
+		httpClient:     httpClient,
+		sessions:       sessions,
+		sessionTTL:     config.SessionTTL,
+		sessionSliding: config.SessionSliding,
+		rolesClaim:     config.RolesClaim,
 	}, nil
 }
 
@@ -84,18 +101,35 @@ func (om *OIDCMiddleware) RequireOIDC() gin.HandlerFunc {
 		}
 
 		// 验证会话
-		session, exists := om.sessions[sessionID]
-		if !exists || session.ExpiresAt.Before(time.Now()) {
+		session, err := om.loadSession(c.Request.Context(), sessionID)
+		if err != nil && !errors.Is(err, errSessionInvalid) {
+			log.Printf("读取会话失败: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Session store unavailable"})
+			return
+		}
+		if session == nil || session.ExpiresAt.Before(time.Now()) {
 			// 会话不存在或已过期
-			delete(om.sessions, sessionID)
 			om.HandleLogin(c)
 			c.Abort()
 			return
 		}
 
-		// 已认证，将用户信息存储到 context 中
+		// 滑动过期：剩余有效期不足一半时续期，避免每个请求都写存储
+		if om.sessionSliding && om.sessionTTL > 0 && time.Until(session.ExpiresAt) < om.sessionTTL/2 {
+			session.ExpiresAt = time.Now().Add(om.sessionTTL)
+			if err := om.saveSession(c.Request.Context(), sessionID, session); err != nil {
+				log.Printf("会话续期失败: %v", err)
+			} else {
+				c.SetCookie("session_id", sessionID, int(om.sessionTTL.Seconds()), "/", "", false, true)
+			}
+		}
+
+		// 已认证，将用户信息存储到 context 中，user_id 与 JWT、API key 认证保持一致
+		userID, _ := session.UserInfo["sub"].(string)
+		c.Set("user_id", userID)
 		c.Set("oidc_session", session)
 		c.Set("user_info", session.UserInfo)
+		c.Set("roles", session.Roles)
 		c.Next()
 	}
 }
@@ -113,27 +147,22 @@ func (om *OIDCMiddleware) HandleLogin(c *gin.Context) {
 
 	// 重定向到 OIDC Provider 的授权页面
 	authURL := om.oauth2Config.AuthCodeURL(state)
-	
-	// 打印调试信息
-	fmt.Println("========================================")
-	fmt.Println("OIDC Login Request:")
-	fmt.Printf("  - Auth URL: %s\n", authURL)
-	fmt.Printf("  - Redirect URI in config: %s\n", om.oauth2Config.RedirectURL)
-	fmt.Println("========================================")
-	
+
+	// 记录调试信息
+	log.Printf("OIDC 登录请求: auth_url=%s redirect_uri=%s", authURL, om.oauth2Config.RedirectURL)
+
 	c.Redirect(http.StatusFound, authURL)
 }
 
 // HandleCallback 处理 OIDC 回调
 func (om *OIDCMiddleware) HandleCallback(c *gin.Context) {
-	ctx := context.Background()
+	// 使用请求 context，使请求截止时间与链路信息传递到 token 交换与验证
+	ctx := oidc.ClientContext(c.Request.Context(), om.httpClient)
 
 	// 检查是否有错误参数
 	if errParam := c.Query("error"); errParam != "" {
 		errDesc := c.Query("error_description")
-		fmt.Printf("⚠️  OIDC 认证失败:\n")
-		fmt.Printf("   错误类型: %s\n", errParam)
-		fmt.Printf("   错误描述: %s\n", errDesc)
+		log.Printf("⚠️  OIDC 认证失败: error=%s error_description=%s", errParam, errDesc)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":             errParam,
 			"error_description": errDesc,
@@ -190,33 +219,36 @@ func (om *OIDCMiddleware) HandleCallback(c *gin.Context) {
 
 	// 标准化用户信息（处理字段映射）
 	userInfo := normalizeUserInfo(claims)
+	roles := extractRoles(claims, om.rolesClaim)
 
-	// 打印用户信息用于调试
-	fmt.Println("========================================")
-	fmt.Println("用户认证成功:")
-	fmt.Printf("  - Sub (用户唯一标识): %v\n", userInfo["sub"])
-	fmt.Printf("  - Username: %v\n", userInfo["username"])
-	fmt.Printf("  - Name: %v\n", userInfo["name"])
-	if email, ok := userInfo["email"]; ok {
-		fmt.Printf("  - Email: %v\n", email)
-	}
-	fmt.Println("========================================")
+	// 记录用户信息用于调试
+	log.Printf("OIDC 登录成功: sub=%v username=%v name=%v email=%v roles=%v",
+		userInfo["sub"], userInfo["username"], userInfo["name"], userInfo["email"], roles)
 
-	// 创建会话
+	// 创建会话，配置了 SessionTTL 时以其为准，否则跟随 access token 过期时间
+	expiresAt := oauth2Token.Expiry
+	if om.sessionTTL > 0 {
+		expiresAt = time.Now().Add(om.sessionTTL)
+	}
 	sessionID := generateRandomState()
 	session := &OIDCSession{
 		IDToken:      rawIDToken,
 		AccessToken:  oauth2Token.AccessToken,
 		RefreshToken: oauth2Token.RefreshToken,
 		UserInfo:     userInfo,
-		ExpiresAt:    oauth2Token.Expiry,
+		Roles:        roles,
+		ExpiresAt:    expiresAt,
 	}
 
 	// 存储会话
-	om.sessions[sessionID] = session
+	if err := om.saveSession(c.Request.Context(), sessionID, session); err != nil {
+		log.Printf("保存会话失败: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to save session"})
+		return
+	}
 
 	// 设置会话 cookie
-	c.SetCookie("session_id", sessionID, int(time.Until(oauth2Token.Expiry).Seconds()), "/", "", false, true)
+	c.SetCookie("session_id", sessionID, int(time.Until(expiresAt).Seconds()), "/", "", false, true)
 
 	// 获取登录前的 URL
 	redirectURL, err := c.Cookie("redirect_after_login")
@@ -234,8 +266,10 @@ func (om *OIDCMiddleware) HandleLogout(c *gin.Context) {
 	// 获取会话 ID
 	sessionID, err := c.Cookie("session_id")
 	if err == nil && sessionID != "" {
-		// 删除会话
-		delete(om.sessions, sessionID)
+		// 删除服务端会话，即使 cookie 被留存也无法再使用
+		if err := om.sessions.Delete(c.Request.Context(), sessionID); err != nil {
+			log.Printf("删除会话失败: %v", err)
+		}
 	}
 
 	// 清除 cookie
@@ -253,7 +287,7 @@ func (om *OIDCMiddleware) GetUserInfo(c *gin.Context) {
 	}
 
 	oidcSession := session.(*OIDCSession)
-	
+
 	// 构造返回的用户信息，包含标准化字段和原始字段
 	response := gin.H{
 		"user_info": oidcSession.UserInfo,
@@ -270,10 +304,44 @@ func (om *OIDCMiddleware) GetUserInfo(c *gin.Context) {
 			},
 		},
 	}
-	
+
 	c.JSON(http.StatusOK, response)
 }
 
+// errSessionInvalid 会话不存在或内容无法解析，按未登录处理
+var errSessionInvalid = errors.New("invalid session")
+
+// loadSession 从会话存储读取并反序列化会话
+func (om *OIDCMiddleware) loadSession(ctx context.Context, sessionID string) (*OIDCSession, error) {
+	data, err := om.sessions.Get(ctx, sessionID)
+	if errors.Is(err, session.ErrNotFound) {
+		return nil, errSessionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s OIDCSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, errSessionInvalid
+	}
+	return &s, nil
+}
+
+// saveSession 序列化会话并写入存储，存储侧过期时间与 ExpiresAt 一致；已过期的会话直接删除
+func (om *OIDCMiddleware) saveSession(ctx context.Context, sessionID string, s *OIDCSession) error {
+	ttl := time.Until(s.ExpiresAt)
+	if ttl <= 0 {
+		return om.sessions.Delete(ctx, sessionID)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return om.sessions.Set(ctx, sessionID, data, ttl)
+}
+
 // generateRandomState 生成随机 state 字符串
 func generateRandomState() string {
 	b := make([]byte, 32)
@@ -319,3 +387,32 @@ func normalizeUserInfo(claims map[string]interface{}) map[string]interface{} {
 
 	return userInfo
 }
+
+// extractRoles 按 claim 路径读取用户角色，兼容字符串数组与空格/逗号分隔的字符串
+func extractRoles(claims map[string]interface{}, path string) []string {
+	if path == "" {
+		return nil
+	}
+
+	var value interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[part]
+	}
+
+	var roles []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if role, ok := item.(string); ok && role != "" {
+				roles = append(roles, role)
+			}
+		}
+	case string:
+		roles = strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })
+	}
+	return roles
+}
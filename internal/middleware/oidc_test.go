@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.woa.com/lideding/gin-tai-login/internal/session"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestOIDCMiddleware 创建不连接 Provider 的 OIDC 中间件，仅用于测试会话处理
+func newTestOIDCMiddleware(ttl time.Duration, sliding bool) *OIDCMiddleware {
+	return &OIDCMiddleware{
+		sessions:       session.NewMemoryStore(),
+		sessionTTL:     ttl,
+		sessionSliding: sliding,
+	}
+}
+
+// serveWithSession 携带 session_id cookie 请求受 RequireOIDC 保护的路由
+func serveWithSession(om *OIDCMiddleware, sessionID string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ping", om.RequireOIDC(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireOIDCSlidingRenewal(t *testing.T) {
+	const ttl = time.Hour
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		sliding   bool
+		remaining time.Duration
+		renewed   bool
+	}{
+		{name: "less than half remaining", sliding: true, remaining: 10 * time.Minute, renewed: true},
+		{name: "more than half remaining", sliding: true, remaining: 50 * time.Minute, renewed: false},
+		{name: "sliding disabled", sliding: false, remaining: 10 * time.Minute, renewed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			om := newTestOIDCMiddleware(ttl, tt.sliding)
+			expiresAt := time.Now().Add(tt.remaining)
+			s := &OIDCSession{UserInfo: map[string]interface{}{"sub": "u1"}, ExpiresAt: expiresAt}
+			if err := om.saveSession(ctx, "sid", s); err != nil {
+				t.Fatalf("saveSession: %v", err)
+			}
+
+			w := serveWithSession(om, "sid")
+			if w.Code != http.StatusOK || w.Body.String() != "u1" {
+				t.Fatalf("got %d %q, want 200 with user_id u1", w.Code, w.Body.String())
+			}
+
+			stored, err := om.loadSession(ctx, "sid")
+			if err != nil {
+				t.Fatalf("loadSession: %v", err)
+			}
+			renewed := stored.ExpiresAt.After(expiresAt.Add(time.Minute))
+			if renewed != tt.renewed {
+				t.Fatalf("renewed = %v, want %v (expires_at %s)", renewed, tt.renewed, stored.ExpiresAt)
+			}
+			if renewed && time.Until(stored.ExpiresAt) < ttl-time.Minute {
+				t.Fatalf("renewed session expires in %s, want about %s", time.Until(stored.ExpiresAt), ttl)
+			}
+
+			hasCookie := false
+			for _, c := range w.Result().Cookies() {
+				if c.Name == "session_id" && c.MaxAge == int(ttl.Seconds()) {
+					hasCookie = true
+				}
+			}
+			if hasCookie != tt.renewed {
+				t.Fatalf("session cookie refreshed = %v, want %v", hasCookie, tt.renewed)
+			}
+		})
+	}
+}
+
+func TestRequireOIDCExpiredSessionRedirectsToLogin(t *testing.T) {
+	om := newTestOIDCMiddleware(time.Hour, true)
+
+	w := serveWithSession(om, "unknown")
+	if w.Code != http.StatusFound {
+		t.Fatalf("got %d, want redirect to login", w.Code)
+	}
+}
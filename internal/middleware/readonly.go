@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"git.woa.com/lideding/gin-tai-login/internal/lifecycle"
+	"github.com/gin-gonic/gin"
+)
+
+// RejectWritesWhenReadOnly 只读模式下以 503 拒绝所有写请求，读请求、健康检查不受影响。
+// /admin 下的路由不受限制，以便关闭只读模式；/auth 下的认证路由不修改业务数据，同样放行
+func RejectWritesWhenReadOnly(lc *lifecycle.Lifecycle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !lc.ReadOnly() || !isWriteMethod(c.Request.Method) || isReadOnlyExempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", "60")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service is in read-only mode"})
+	}
+}
+
+// isWriteMethod 是否为会修改数据的请求方法
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// isReadOnlyExempt 只读模式下仍允许写请求的路径
+func isReadOnlyExempt(path string) bool {
+	return strings.HasPrefix(path, "/admin/") || strings.HasPrefix(path, "/auth/")
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"git.woa.com/lideding/gin-tai-login/internal/session"
+)
+
+var (
+	// errRefreshTokenInvalid refresh token 不存在、已过期或已被吊销
+	errRefreshTokenInvalid = errors.New("invalid refresh token")
+	// errRefreshTokenReused 已轮换的 refresh token 被再次使用，整条链已被吊销
+	errRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// refreshFamily 一次登录对应的轮换链。整条链共享登录时确定的绝对过期时间，轮换不会延长，
+// 到期后必须重新登录，角色随之从 IdP 重新获取
+type refreshFamily struct {
+	Subject   string                 `json:"subject"`
+	UserInfo  map[string]interface{} `json:"user_info"`
+	Roles     []string               `json:"roles"`
+	IssuedAt  time.Time              `json:"issued_at"`
+	ExpiresAt time.Time              `json:"expires_at"`
+}
+
+// refreshRecord 单个 refresh token 的记录，以 token 哈希为 key
+type refreshRecord struct {
+	FamilyID string `json:"family_id"`
+}
+
+// refreshStore refresh token 表，保存在会话存储中（Redis 时多实例共享、重启不丢失），只保存 token 的哈希。
+// key 布局：
+//
+//	family:<familyID>  轮换链（用户信息、角色、绝对过期时间），删除即吊销整条链
+//	token:<hash>       refresh token 所属的链
+//	used:<hash>        token 已被轮换的标记，SetNX 保证多实例下只能轮换一次
+//	subject:<sub>      该用户在此时间之前签发的链全部失效
+type refreshStore struct {
+	store session.Store
+	ttl   time.Duration
+	now   func() time.Time // 测试中可替换
+}
+
+func newRefreshStore(store session.Store, ttl time.Duration) *refreshStore {
+	return &refreshStore{store: store, ttl: ttl, now: time.Now}
+}
+
+// start 为一次新的登录开启轮换链
+func (s *refreshStore) start(ctx context.Context, userInfo map[string]interface{}, roles []string) (string, *refreshFamily, error) {
+	now := s.now()
+	subject, _ := userInfo["sub"].(string)
+	family := &refreshFamily{
+		Subject:   subject,
+		UserInfo:  userInfo,
+		Roles:     roles,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	familyID := generateRandomState()
+	if err := s.put(ctx, "family:"+familyID, family, s.ttl); err != nil {
+		return "", nil, err
+	}
+	return familyID, family, nil
+}
+
+// issue 在轮换链上签发新的 refresh token，有效期截止到链的绝对过期时间
+func (s *refreshStore) issue(ctx context.Context, familyID string, family *refreshFamily) (string, error) {
+	token := generateRandomState()
+	rec := refreshRecord{FamilyID: familyID}
+	if err := s.put(ctx, "token:"+hashToken(token), rec, family.ExpiresAt.Sub(s.now())); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// rotate 校验并消费 refresh token，返回其所属的链；重复使用时吊销整条链，
+// 同时返回链信息供记录安全日志
+func (s *refreshStore) rotate(ctx context.Context, token string) (string, *refreshFamily, error) {
+	h := hashToken(token)
+	familyID, family, err := s.lookup(ctx, h)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ok, err := s.store.SetNX(ctx, "used:"+h, []byte("1"), family.ExpiresAt.Sub(s.now()))
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		if err := s.store.Delete(ctx, "family:"+familyID); err != nil {
+			return "", nil, err
+		}
+		return familyID, family, errRefreshTokenReused
+	}
+	return familyID, family, nil
+}
+
+// revoke 吊销 token 所在的整条轮换链，token 无效时不做任何事
+func (s *refreshStore) revoke(ctx context.Context, token string) error {
+	var rec refreshRecord
+	if err := s.get(ctx, "token:"+hashToken(token), &rec); err != nil {
+		if errors.Is(err, errRefreshTokenInvalid) {
+			return nil
+		}
+		return err
+	}
+	return s.store.Delete(ctx, "family:"+rec.FamilyID)
+}
+
+// revokeSubject 吊销某个用户在此刻之前签发的全部轮换链
+func (s *refreshStore) revokeSubject(ctx context.Context, subject string) error {
+	return s.put(ctx, "subject:"+subject, s.now(), s.ttl)
+}
+
+// lookup 按 token 哈希查找仍然有效的轮换链
+func (s *refreshStore) lookup(ctx context.Context, h string) (string, *refreshFamily, error) {
+	var rec refreshRecord
+	if err := s.get(ctx, "token:"+h, &rec); err != nil {
+		return "", nil, err
+	}
+
+	var family refreshFamily
+	if err := s.get(ctx, "family:"+rec.FamilyID, &family); err != nil {
+		return "", nil, err
+	}
+	if !family.ExpiresAt.After(s.now()) {
+		return "", nil, errRefreshTokenInvalid
+	}
+
+	var revokedAt time.Time
+	err := s.get(ctx, "subject:"+family.Subject, &revokedAt)
+	if err == nil && !family.IssuedAt.After(revokedAt) {
+		return "", nil, errRefreshTokenInvalid
+	}
+	if err != nil && !errors.Is(err, errRefreshTokenInvalid) {
+		return "", nil, err
+	}
+	return rec.FamilyID, &family, nil
+}
+
+// get 读取并反序列化记录，记录不存在或无法解析时返回 errRefreshTokenInvalid
+func (s *refreshStore) get(ctx context.Context, id string, v interface{}) error {
+	data, err := s.store.Get(ctx, id)
+	if errors.Is(err, session.ErrNotFound) {
+		return errRefreshTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errRefreshTokenInvalid
+	}
+	return nil
+}
+
+// put 序列化并写入记录，ttl 不为正说明链已过期
+func (s *refreshStore) put(ctx context.Context, id string, v interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errRefreshTokenInvalid
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, id, data, ttl)
+}
+
+// hashToken 服务端只保存 token 的 SHA-256，存储泄露时无法直接使用
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
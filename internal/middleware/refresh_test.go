@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"git.woa.com/lideding/gin-tai-login/internal/session"
+)
+
+// startTestFamily 开启一条轮换链并签发第一个 refresh token
+func startTestFamily(t *testing.T, s *refreshStore, sub string) string {
+	t.Helper()
+	ctx := context.Background()
+	familyID, family, err := s.start(ctx, map[string]interface{}{"sub": sub}, []string{"viewer"})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	token, err := s.issue(ctx, familyID, family)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	return token
+}
+
+// rotateOnce 轮换 token 并签发链上的下一个 token
+func rotateOnce(t *testing.T, s *refreshStore, token string) (string, *refreshFamily) {
+	t.Helper()
+	ctx := context.Background()
+	familyID, family, err := s.rotate(ctx, token)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	next, err := s.issue(ctx, familyID, family)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	return next, family
+}
+
+func TestRefreshRotationReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	s := newRefreshStore(session.NewMemoryStore(), time.Hour)
+
+	first := startTestFamily(t, s, "u1")
+	second, _ := rotateOnce(t, s, first)
+
+	// 旧 token 再次出现：整条链被吊销，包括刚轮换出的新 token
+	familyID, family, err := s.rotate(ctx, first)
+	if !errors.Is(err, errRefreshTokenReused) {
+		t.Fatalf("reuse: want errRefreshTokenReused, got %v", err)
+	}
+	if family.Subject != "u1" || familyID == "" {
+		t.Fatalf("reuse: want family info for logging, got %q %+v", familyID, family)
+	}
+	if _, _, err := s.rotate(ctx, second); !errors.Is(err, errRefreshTokenInvalid) {
+		t.Fatalf("rotate after reuse: want errRefreshTokenInvalid, got %v", err)
+	}
+}
+
+func TestRefreshRotationKeepsAbsoluteExpiry(t *testing.T) {
+	clock := time.Now()
+	s := newRefreshStore(session.NewMemoryStore(), time.Hour)
+	s.now = func() time.Time { return clock }
+
+	token := startTestFamily(t, s, "u1")
+	var family *refreshFamily
+	token, family = rotateOnce(t, s, token)
+	expiresAt := family.ExpiresAt
+
+	clock = clock.Add(40 * time.Minute)
+	token, family = rotateOnce(t, s, token)
+	if !family.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("rotation extended expiry: %v -> %v", expiresAt, family.ExpiresAt)
+	}
+
+	// 持续轮换也无法越过登录时确定的过期时间
+	clock = clock.Add(40 * time.Minute)
+	if _, _, err := s.rotate(context.Background(), token); !errors.Is(err, errRefreshTokenInvalid) {
+		t.Fatalf("rotate after absolute expiry: want errRefreshTokenInvalid, got %v", err)
+	}
+}
+
+func TestRefreshRevokeSubject(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Now()
+	s := newRefreshStore(session.NewMemoryStore(), time.Hour)
+	s.now = func() time.Time { return clock }
+
+	revoked := startTestFamily(t, s, "u1")
+	other := startTestFamily(t, s, "u2")
+
+	if err := s.revokeSubject(ctx, "u1"); err != nil {
+		t.Fatalf("revokeSubject: %v", err)
+	}
+	if _, _, err := s.rotate(ctx, revoked); !errors.Is(err, errRefreshTokenInvalid) {
+		t.Fatalf("rotate revoked subject: want errRefreshTokenInvalid, got %v", err)
+	}
+	if _, _, err := s.rotate(ctx, other); err != nil {
+		t.Fatalf("rotate other subject: %v", err)
+	}
+
+	// 吊销之后的新登录不受影响
+	clock = clock.Add(time.Second)
+	fresh := startTestFamily(t, s, "u1")
+	if _, _, err := s.rotate(ctx, fresh); err != nil {
+		t.Fatalf("rotate after re-login: %v", err)
+	}
+}
+
+func TestRefreshRevoke(t *testing.T) {
+	ctx := context.Background()
+	s := newRefreshStore(session.NewMemoryStore(), time.Hour)
+
+	token := startTestFamily(t, s, "u1")
+	if err := s.revoke(ctx, token); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, _, err := s.rotate(ctx, token); !errors.Is(err, errRefreshTokenInvalid) {
+		t.Fatalf("rotate after revoke: want errRefreshTokenInvalid, got %v", err)
+	}
+	if err := s.revoke(ctx, "unknown"); err != nil {
+		t.Fatalf("revoke unknown token: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"git.woa.com/lideding/gin-tai-login/internal/authz"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole 要求当前用户具备任一指定角色（内置角色按 admin ⊇ editor ⊇ viewer 继承），
+// 需放在设置 roles 的认证中间件之后
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		have := c.GetStringSlice("roles")
+		for _, want := range roles {
+			if authz.HasRole(have, want) {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+	}
+}
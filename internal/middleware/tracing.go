@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"git.woa.com/lideding/gin-tai-login/internal/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// TraceContext 解析入站 traceparent（缺失或非法时开启新链路），
+// 存入请求 context，供出站 HTTP 调用继续传递
+func TraceContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sc, ok := tracing.ParseTraceparent(c.GetHeader(tracing.HeaderTraceparent))
+		if ok {
+			sc.TraceState = c.GetHeader(tracing.HeaderTracestate)
+			// 本服务作为上游 span 的子 span
+			sc = sc.Child()
+		} else {
+			sc = tracing.NewRoot()
+		}
+
+		c.Set("trace_id", sc.TraceID)
+		c.Request = c.Request.WithContext(tracing.ContextWithSpan(c.Request.Context(), sc))
+		c.Next()
+	}
+}
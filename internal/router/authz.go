@@ -0,0 +1,44 @@
+package router
+
+import (
+	"net/http"
+
+	"git.woa.com/lideding/gin-tai-login/internal/authz"
+)
+
+// routeRules 全部路由的授权矩阵，新增路由时需同步登记。
+// 每个路由组只放行访问级别与之相同的已登记路由，未登记的路由启动时告警、请求时拒绝；
+// /admin/authz 展示实际生效的矩阵
+var routeRules = []authz.Rule{
+	// 公开路由
+	{Method: http.MethodGet, Path: "/hi", Access: authz.AccessPublic},
+	{Method: http.MethodGet, Path: "/readyz", Access: authz.AccessPublic},
+	{Method: http.MethodGet, Path: "/auth/login", Access: authz.AccessPublic},
+	{Method: http.MethodGet, Path: "/auth/logout", Access: authz.AccessPublic},
+	{Method: http.MethodGet, Path: "/auth/callback", Access: authz.AccessPublic},
+	{Method: http.MethodPost, Path: "/auth/refresh", Access: authz.AccessPublic},
+	{Method: http.MethodPost, Path: "/auth/revoke", Access: authz.AccessPublic},
+
+	// 调试路由（DEBUG_ENDPOINTS_ENABLED 开启时注册）
+	{Method: authz.AnyMethod, Path: "/debug/echo", Access: authz.AccessPublic},
+	{Method: authz.AnyMethod, Path: "/debug/noop", Access: authz.AccessPublic},
+
+	// 受保护路由
+	{Method: http.MethodGet, Path: "/ping", Access: authz.AccessAuthenticated},
+	{Method: http.MethodGet, Path: "/auth/userinfo", Access: authz.AccessAuthenticated},
+	{Method: http.MethodPost, Path: "/auth/token", Access: authz.AccessAuthenticated, Roles: []string{authz.RoleViewer}},
+
+	// API 路由（配置 JWT 密钥或开启 API key 时注册）
+	{Method: http.MethodGet, Path: "/api/ping", Access: authz.AccessAPIClient, Roles: []string{authz.RoleViewer}},
+
+	// 管理路由
+	{Method: http.MethodPost, Path: "/admin/quitquitquit", Access: authz.AccessAdminToken},
+	{Method: http.MethodGet, Path: "/admin/read-only", Access: authz.AccessAdminToken},
+	{Method: http.MethodPut, Path: "/admin/read-only", Access: authz.AccessAdminToken},
+	{Method: http.MethodPost, Path: "/admin/loadgen", Access: authz.AccessAdminToken},
+	{Method: http.MethodGet, Path: "/admin/authz", Access: authz.AccessAdminToken},
+	{Method: http.MethodDelete, Path: "/admin/refresh-tokens/:sub", Access: authz.AccessAdminToken},
+	{Method: http.MethodPost, Path: "/admin/api-keys", Access: authz.AccessAdminToken},
+	{Method: http.MethodGet, Path: "/admin/api-keys", Access: authz.AccessAdminToken},
+	{Method: http.MethodDelete, Path: "/admin/api-keys/:id", Access: authz.AccessAdminToken},
+}
@@ -1,25 +1,68 @@
 package router
 
 import (
+	"log"
+
+	"git.woa.com/lideding/gin-tai-login/internal/authz"
+	"git.woa.com/lideding/gin-tai-login/internal/config"
 	"git.woa.com/lideding/gin-tai-login/internal/handler"
+	"git.woa.com/lideding/gin-tai-login/internal/lifecycle"
+	"git.woa.com/lideding/gin-tai-login/internal/loadgen"
 	"git.woa.com/lideding/gin-tai-login/internal/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRouter 配置并返回 Gin 路由引擎
-func SetupRouter(oidcMw *middleware.OIDCMiddleware) *gin.Engine {
-	r := gin.Default()
+func SetupRouter(cfg *config.Config, oidcMw *middleware.OIDCMiddleware, jwtMw *middleware.JWTMiddleware, apiKeyMw *middleware.APIKeyMiddleware, lc *lifecycle.Lifecycle) *gin.Engine {
+	r := gin.New()
+
+	// 访问日志与 panic 恢复；loadgen 生成的合成流量不写访问日志，避免高 RPS 时刷满日志文件
+	r.Use(gin.LoggerWithConfig(gin.LoggerConfig{
+		Skip: func(c *gin.Context) bool { return loadgen.IsSynthetic(c.Request) },
+	}))
+	r.Use(gin.Recovery())
+
+	// 解析 W3C traceparent，供出站调用继续传递
+	r.Use(middleware.TraceContext())
+
+	// 根据上游传入的截止时间限制请求处理时长
+	r.Use(middleware.RequestDeadline(cfg.Server.RequestTimeout))
+
+	// 只读模式下统一拒绝写请求
+	r.Use(middleware.RejectWritesWhenReadOnly(lc))
+
+	// 路由授权矩阵：引擎级拒绝未登记的路由，每个路由组再按自身访问级别执行
+	matrix := authz.NewMatrix(routeRules)
+	if err := matrix.Validate(); err != nil {
+		log.Fatalf("授权矩阵无效: %v", err)
+	}
+	r.Use(middleware.DenyUnlisted(matrix))
 
-	// 创建 OIDC Handler
+	// 创建 Handler
 	oidcHandler := handler.NewOIDCHandler(oidcMw)
+	lifecycleHandler := handler.NewLifecycleHandler(lc)
 
 	// ========================================
 	// 公开路由（无需认证）
 	// ========================================
 	public := r.Group("/")
-	RegisterHealthPublicRoutes(public)
+	public.Use(middleware.Authorize(matrix, authz.AccessPublic))
+	RegisterHealthPublicRoutes(public, lifecycleHandler)
 	RegisterOIDCPublicRoutes(public, oidcHandler)
 
+	var jwtHandler *handler.JWTHandler
+	if jwtMw != nil {
+		jwtHandler = handler.NewJWTHandler(jwtMw)
+		RegisterJWTPublicRoutes(public, jwtHandler)
+	}
+
+	// 调试路由仅经过中间件栈，用于区分框架开销与业务耗时
+	if cfg.Server.DebugEndpoints {
+		debug := r.Group("/debug")
+		debug.Use(middleware.Authorize(matrix, authz.AccessPublic))
+		RegisterDebugRoutes(debug)
+	}
+
 	// ========================================
 	// 受保护路由（需要 OIDC 认证）
 	// ========================================
@@ -28,15 +71,75 @@ func SetupRouter(oidcMw *middleware.OIDCMiddleware) *gin.Engine {
 	if oidcMw != nil {
 		protected.Use(oidcMw.RequireOIDC())
 	}
+	protected.Use(middleware.Authorize(matrix, authz.AccessAuthenticated))
 	RegisterHealthProtectedRoutes(protected)
 	RegisterOIDCProtectedRoutes(protected, oidcHandler)
 
+	// 签发的 JWT 用于访问 /api，与 /api 一样要求至少具备 viewer 角色
+	if jwtMw != nil {
+		RegisterJWTProtectedRoutes(protected.Group("/", middleware.RequireRole(authz.RoleViewer)), jwtHandler)
+	}
+
+	// ========================================
+	// API 路由（供脚本/批处理等非浏览器客户端使用，Bearer JWT 或 X-API-Key）
+	// ========================================
+	if jwtMw != nil || apiKeyMw != nil {
+		var requireJWT gin.HandlerFunc
+		if jwtMw != nil {
+			requireJWT = jwtMw.RequireJWT()
+		}
+
+		api := r.Group("/api")
+		if apiKeyMw != nil {
+			api.Use(apiKeyMw.RequireAPIKeyOr(requireJWT))
+		} else {
+			api.Use(requireJWT)
+		}
+		// 机器客户端需显式授予角色，至少为 viewer
+		api.Use(middleware.RequireRole(authz.RoleViewer))
+		api.Use(middleware.Authorize(matrix, authz.AccessAPIClient))
+		RegisterAPIRoutes(api)
+	}
+
+	// ========================================
+	// 管理路由（需要 X-Admin-Token，未配置 ADMIN_TOKEN 时不开放）
+	// ========================================
+	if cfg.Admin.Token != "" {
+		admin := r.Group("/admin")
+		admin.Use(middleware.RequireAdminToken(cfg.Admin.Token))
+		admin.Use(middleware.Authorize(matrix, authz.AccessAdminToken))
+		RegisterAdminRoutes(admin, lifecycleHandler, handler.NewAuthzHandler(matrix, r))
+		if jwtHandler != nil {
+			admin.DELETE("/refresh-tokens/:sub", jwtHandler.HandleRevokeSubject)
+		}
+		if apiKeyMw != nil {
+			RegisterAPIKeyAdminRoutes(admin, handler.NewAPIKeyHandler(apiKeyMw))
+		}
+
+		// 合成流量压测直接驱动本路由引擎，需单独开启
+		if cfg.Admin.LoadgenEnabled {
+			admin.POST("/loadgen", handler.NewLoadgenHandler(r).Run)
+		}
+	}
+
+	warnUnlistedRoutes(r, matrix)
+
 	return r
 }
 
+// warnUnlistedRoutes 对未在授权矩阵中登记的路由输出告警，这些路由的请求会被 DenyUnlisted 拒绝
+func warnUnlistedRoutes(r *gin.Engine, matrix *authz.Matrix) {
+	for _, route := range r.Routes() {
+		if _, ok := matrix.Lookup(route.Method, route.Path); !ok {
+			log.Printf("⚠️  路由 %s %s 未在授权矩阵中登记，请求将被拒绝", route.Method, route.Path)
+		}
+	}
+}
+
 // RegisterHealthPublicRoutes 注册公开的健康检查路由
-func RegisterHealthPublicRoutes(rg *gin.RouterGroup) {
+func RegisterHealthPublicRoutes(rg *gin.RouterGroup, h *handler.LifecycleHandler) {
 	rg.GET("/hi", handler.Hi)
+	rg.GET("/readyz", h.Readyz)
 }
 
 // RegisterHealthProtectedRoutes 注册受保护的健康检查路由
@@ -44,6 +147,12 @@ func RegisterHealthProtectedRoutes(rg *gin.RouterGroup) {
 	rg.GET("/ping", handler.Ping)
 }
 
+// RegisterDebugRoutes 注册调试路由
+func RegisterDebugRoutes(rg *gin.RouterGroup) {
+	rg.Any("/echo", handler.Echo)
+	rg.Any("/noop", handler.Noop)
+}
+
 // RegisterOIDCPublicRoutes 注册公开的 OIDC 路由
 func RegisterOIDCPublicRoutes(rg *gin.RouterGroup, h *handler.OIDCHandler) {
 	// oidc := rg.Group("/auth")
@@ -66,3 +175,40 @@ func RegisterOIDCProtectedRoutes(rg *gin.RouterGroup, h *handler.OIDCHandler) {
 		oidc.GET("/userinfo", h.HandleUserInfo)
 	}
 }
+
+// RegisterJWTPublicRoutes 注册公开的 refresh token 路由
+func RegisterJWTPublicRoutes(rg *gin.RouterGroup, h *handler.JWTHandler) {
+	auth := rg.Group("/auth")
+	{
+		auth.POST("/refresh", h.HandleRefresh)
+		auth.POST("/revoke", h.HandleRevoke)
+	}
+}
+
+// RegisterJWTProtectedRoutes 注册受保护的 JWT 签发路由
+func RegisterJWTProtectedRoutes(rg *gin.RouterGroup, h *handler.JWTHandler) {
+	rg.POST("/auth/token", h.HandleIssueToken)
+}
+
+// RegisterAPIRoutes 注册 Bearer JWT / API key 保护的 API 路由
+func RegisterAPIRoutes(rg *gin.RouterGroup) {
+	rg.GET("/ping", handler.Ping)
+}
+
+// RegisterAPIKeyAdminRoutes 注册 API key 管理路由
+func RegisterAPIKeyAdminRoutes(rg *gin.RouterGroup, h *handler.APIKeyHandler) {
+	keys := rg.Group("/api-keys")
+	{
+		keys.POST("", h.HandleCreate)
+		keys.GET("", h.HandleList)
+		keys.DELETE("/:id", h.HandleRevoke)
+	}
+}
+
+// RegisterAdminRoutes 注册管理路由
+func RegisterAdminRoutes(rg *gin.RouterGroup, lh *handler.LifecycleHandler, ah *handler.AuthzHandler) {
+	rg.POST("/quitquitquit", lh.Quit)
+	rg.GET("/read-only", lh.GetReadOnly)
+	rg.PUT("/read-only", lh.SetReadOnly)
+	rg.GET("/authz", ah.Matrix)
+}
@@ -0,0 +1,96 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 每写入多少次清理一遍过期会话，把全表扫描的开销分摊到多次写入上
+const sweepInterval = 1024
+
+// memoryEntry 内存中的单个会话
+type memoryEntry struct {
+	data      []byte
+	expiresAt time.Time // 零值表示不过期
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !e.expiresAt.After(now)
+}
+
+// MemoryStore 进程内存会话存储，仅适用于单实例部署，重启后会话丢失
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]memoryEntry
+	writes   int // 距上次清理的写入次数
+}
+
+// NewMemoryStore 创建内存会话存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]memoryEntry)}
+}
+
+// Get 读取会话，已过期的会话会被顺带删除
+func (s *MemoryStore) Get(_ context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if entry.expired(time.Now()) {
+		delete(s.sessions, id)
+		return nil, ErrNotFound
+	}
+	return entry.data, nil
+}
+
+// Set 写入会话，ttl 为 0 时不过期；每 sweepInterval 次写入顺带清理已过期的会话
+func (s *MemoryStore) Set(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setLocked(id, data, ttl)
+	return nil
+}
+
+// SetNX 仅在会话不存在（或已过期）时写入
+func (s *MemoryStore) SetNX(_ context.Context, id string, data []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.sessions[id]; ok && !entry.expired(time.Now()) {
+		return false, nil
+	}
+	s.setLocked(id, data, ttl)
+	return true, nil
+}
+
+func (s *MemoryStore) setLocked(id string, data []byte, ttl time.Duration) {
+	now := time.Now()
+	if s.writes++; s.writes >= sweepInterval {
+		s.writes = 0
+		for k, entry := range s.sessions {
+			if entry.expired(now) {
+				delete(s.sessions, k)
+			}
+		}
+	}
+
+	entry := memoryEntry{data: data}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+	s.sessions[id] = entry
+}
+
+// Delete 删除会话
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	store.Set(ctx, "short", []byte("v"), 10*time.Millisecond)
+	store.Set(ctx, "forever", []byte("v"), 0)
+
+	if _, err := store.Get(ctx, "short"); err != nil {
+		t.Fatalf("Get before expiry: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := store.Get(ctx, "short"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after expiry: want ErrNotFound, got %v", err)
+	}
+	if _, err := store.Get(ctx, "forever"); err != nil {
+		t.Fatalf("Get with ttl 0: %v", err)
+	}
+}
+
+func TestMemoryStoreDelete(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	store.Set(ctx, "a", []byte("v"), time.Minute)
+	if err := store.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: want ErrNotFound, got %v", err)
+	}
+	if err := store.Delete(ctx, "missing"); err != nil {
+		t.Fatalf("Delete missing: %v", err)
+	}
+}
+
+func TestMemoryStoreSetNX(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if ok, _ := store.SetNX(ctx, "a", []byte("1"), 10*time.Millisecond); !ok {
+		t.Fatal("first SetNX: want true")
+	}
+	if ok, _ := store.SetNX(ctx, "a", []byte("2"), time.Minute); ok {
+		t.Fatal("second SetNX: want false while the key exists")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if ok, _ := store.SetNX(ctx, "a", []byte("3"), time.Minute); !ok {
+		t.Fatal("SetNX after expiry: want true")
+	}
+}
+
+func TestMemoryStoreSweepsExpiredEntriesPeriodically(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	store.Set(ctx, "stale", []byte("v"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// 未到清理周期时，过期会话仍留在表中（读取时才删除）
+	store.Set(ctx, "fresh", []byte("v"), time.Minute)
+	if _, ok := store.sessions["stale"]; !ok {
+		t.Fatal("expired entry swept before sweepInterval writes")
+	}
+
+	// 已写入 2 次，补足到 sweepInterval-1 次
+	for i := 2; i < sweepInterval-1; i++ {
+		store.Set(ctx, "fresh", []byte("v"), time.Minute)
+	}
+	if _, ok := store.sessions["stale"]; !ok {
+		t.Fatal("expired entry swept early")
+	}
+	store.Set(ctx, "fresh", []byte("v"), time.Minute)
+	if _, ok := store.sessions["stale"]; ok {
+		t.Fatal("expired entry not swept after sweepInterval writes")
+	}
+}
@@ -0,0 +1,69 @@
+package session
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore 基于 Redis 的会话存储，多实例共享会话，过期由 Redis TTL 控制。
+// 连接池、断线重连与失效连接的重试由 go-redis 负责
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore 创建 Redis 会话存储，连接在首次使用时建立
+func NewRedisStore(cfg Config) *RedisStore {
+	opts := &redis.Options{
+		Addr:        cfg.RedisAddr,
+		Username:    cfg.RedisUsername,
+		Password:    cfg.RedisPassword,
+		DB:          cfg.RedisDB,
+		DialTimeout: cfg.DialTimeout,
+	}
+	if cfg.RedisTLS {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return &RedisStore{
+		client: redis.NewClient(opts),
+		prefix: cfg.KeyPrefix,
+	}
+}
+
+// Ping 检查 Redis 是否可用
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Get 读取会话
+func (s *RedisStore) Get(ctx context.Context, id string) ([]byte, error) {
+	data, err := s.client.Get(ctx, s.prefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Set 写入会话，ttl 为 0 时不过期
+func (s *RedisStore) Set(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+id, data, ttl).Err()
+}
+
+// SetNX 仅在 key 不存在时写入（SET NX）
+func (s *RedisStore) SetNX(ctx context.Context, id string, data []byte, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.prefix+id, data, ttl).Result()
+}
+
+// Delete 删除会话
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	return s.client.Del(ctx, s.prefix+id).Err()
+}
+
+// Close 关闭连接池
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,233 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis 实现 GET/SET/SETNX/DEL/PING 的最小 RESP2 服务端，用于在没有 Redis 的环境下测试 RedisStore
+type fakeRedis struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	data  map[string]string
+	conns map[net.Conn]struct{}
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{ln: ln, data: make(map[string]string), conns: make(map[net.Conn]struct{})}
+	go f.serve()
+	t.Cleanup(func() { ln.Close(); f.dropConnections() })
+	return f
+}
+
+func (f *fakeRedis) addr() string {
+	return f.ln.Addr().String()
+}
+
+// dropConnections 关闭所有已建立的连接，模拟 Redis 重启或空闲连接超时
+func (f *fakeRedis) dropConnections() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for c := range f.conns {
+		c.Close()
+		delete(f.conns, c)
+	}
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conns[conn] = struct{}{}
+		f.mu.Unlock()
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		fmt.Fprint(conn, f.exec(args))
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "SET":
+		for _, opt := range args[3:] {
+			if _, exists := f.data[args[1]]; exists && strings.EqualFold(opt, "NX") {
+				return "$-1\r\n"
+			}
+		}
+		f.data[args[1]] = args[2]
+		return "+OK\r\n"
+	case "SETNX":
+		if _, exists := f.data[args[1]]; exists {
+			return ":0\r\n"
+		}
+		f.data[args[1]] = args[2]
+		return ":1\r\n"
+	case "GET":
+		v, ok := f.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "DEL":
+		_, ok := f.data[args[1]]
+		delete(f.data, args[1])
+		if ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	default:
+		// HELLO、CLIENT SETINFO 等握手命令按旧版本 Redis 处理，客户端会回退到 RESP2
+		return "-ERR unknown command\r\n"
+	}
+}
+
+// readCommand 读取一条 RESP 数组形式的命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected command line %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeRedis(t)
+	store, err := NewStore(ctx, Config{Backend: BackendRedis, RedisAddr: srv.addr(), KeyPrefix: "test:"})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	if _, err := store.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get missing: want ErrNotFound, got %v", err)
+	}
+
+	if err := store.Set(ctx, "a", []byte(`{"sub":"u1"}`), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	srv.mu.Lock()
+	_, ok := srv.data["test:a"]
+	srv.mu.Unlock()
+	if !ok {
+		t.Fatal("Set did not apply key prefix")
+	}
+
+	data, err := store.Get(ctx, "a")
+	if err != nil || string(data) != `{"sub":"u1"}` {
+		t.Fatalf("Get: got %q, %v", data, err)
+	}
+
+	if err := store.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestRedisStoreSetNX(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeRedis(t)
+	store, err := NewStore(ctx, Config{Backend: BackendRedis, RedisAddr: srv.addr()})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	for i, want := range []bool{true, false} {
+		ok, err := store.SetNX(ctx, "lock", []byte("1"), time.Minute)
+		if err != nil || ok != want {
+			t.Fatalf("SetNX #%d: got %v, %v; want %v", i+1, ok, err, want)
+		}
+	}
+}
+
+func TestRedisStoreReconnectsAfterDroppedConnection(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeRedis(t)
+	store, err := NewStore(ctx, Config{Backend: BackendRedis, RedisAddr: srv.addr()})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := store.Set(ctx, "a", []byte("v"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// 连接池中的空闲连接已被服务端关闭，下一次调用应重新建连而不是返回错误
+	srv.dropConnections()
+
+	data, err := store.Get(ctx, "a")
+	if err != nil || string(data) != "v" {
+		t.Fatalf("Get after dropped connection: got %q, %v", data, err)
+	}
+}
+
+func TestNewStoreRedisUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	_, err = NewStore(context.Background(), Config{Backend: BackendRedis, RedisAddr: addr, DialTimeout: time.Second})
+	if err == nil {
+		t.Fatal("NewStore: want error for unreachable redis")
+	}
+}
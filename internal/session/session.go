@@ -0,0 +1,88 @@
+// Package session 提供服务端会话存储，支持进程内存与 Redis 两种后端
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 会话存储后端
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// ErrNotFound 会话不存在或已过期
+var ErrNotFound = errors.New("session not found")
+
+// Store 会话存储，值为调用方序列化后的会话数据，过期由存储负责
+type Store interface {
+	// Get 读取会话，不存在或已过期时返回 ErrNotFound
+	Get(ctx context.Context, id string) ([]byte, error)
+	// Set 写入会话并（重新）设置过期时间，ttl 为 0 时不过期
+	Set(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	// SetNX 仅在 id 不存在时写入，返回是否写入成功；多实例间的原子占位
+	SetNX(ctx context.Context, id string, data []byte, ttl time.Duration) (bool, error)
+	// Delete 删除会话，会话不存在时不报错
+	Delete(ctx context.Context, id string) error
+}
+
+// Config 会话存储配置
+type Config struct {
+	Backend       string        // 存储后端：memory（默认）或 redis
+	RedisAddr     string        // Redis 地址，例如 127.0.0.1:6379
+	RedisUsername string        // Redis ACL 用户名，为空时使用 default 用户
+	RedisPassword string        // Redis 密码
+	RedisDB       int           // Redis 数据库编号
+	RedisTLS      bool          // 是否使用 TLS 连接 Redis
+	KeyPrefix     string        // Redis key 前缀，各类数据再通过 Prefixed 区分
+	DialTimeout   time.Duration // 连接 Redis 的超时时间
+}
+
+// NewStore 按配置创建会话存储；使用 Redis 时会先 PING 确认可用
+func NewStore(ctx context.Context, cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendRedis:
+		if cfg.RedisAddr == "" {
+			return nil, errors.New("redis session store requires an address")
+		}
+		store := NewRedisStore(cfg)
+		if err := store.Ping(ctx); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("failed to connect to redis: %w", err)
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown session store backend %q", cfg.Backend)
+	}
+}
+
+// Prefixed 返回在 id 前加上 prefix 的 Store，多类数据共用同一存储时用于隔离 key
+func Prefixed(s Store, prefix string) Store {
+	return prefixedStore{store: s, prefix: prefix}
+}
+
+type prefixedStore struct {
+	store  Store
+	prefix string
+}
+
+func (p prefixedStore) Get(ctx context.Context, id string) ([]byte, error) {
+	return p.store.Get(ctx, p.prefix+id)
+}
+
+func (p prefixedStore) Set(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	return p.store.Set(ctx, p.prefix+id, data, ttl)
+}
+
+func (p prefixedStore) SetNX(ctx context.Context, id string, data []byte, ttl time.Duration) (bool, error) {
+	return p.store.SetNX(ctx, p.prefix+id, data, ttl)
+}
+
+func (p prefixedStore) Delete(ctx context.Context, id string) error {
+	return p.store.Delete(ctx, p.prefix+id)
+}
@@ -0,0 +1,129 @@
+// Package tracing 实现 W3C Trace Context（traceparent）在入站请求与出站调用之间的传递
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// HeaderTraceparent W3C traceparent 请求头
+	HeaderTraceparent = "traceparent"
+	// HeaderTracestate W3C tracestate 请求头
+	HeaderTracestate = "tracestate"
+)
+
+// SpanContext 当前请求所属的链路信息
+type SpanContext struct {
+	TraceID    string // 32 位十六进制链路 ID
+	SpanID     string // 16 位十六进制 span ID
+	Flags      string // 2 位十六进制 trace-flags
+	TraceState string // 原样透传的 tracestate
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan 将 SpanContext 存入 context
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanFromContext 从 context 中取出 SpanContext
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// ParseTraceparent 解析 traceparent 请求头，格式为 version-traceid-spanid-flags
+func ParseTraceparent(v string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	// version 00 严格要求 4 段
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	sc := SpanContext{TraceID: parts[1], SpanID: parts[2], Flags: parts[3]}
+	if !isHex(parts[0], 2) || !isHex(sc.TraceID, 32) || !isHex(sc.SpanID, 16) || !isHex(sc.Flags, 2) {
+		return SpanContext{}, false
+	}
+	if isZero(sc.TraceID) || isZero(sc.SpanID) {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+// Traceparent 格式化为 traceparent 请求头的值
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// NewRoot 生成新的根 SpanContext（默认采样）
+func NewRoot() SpanContext {
+	return SpanContext{TraceID: randomHex(16), SpanID: randomHex(8), Flags: "01"}
+}
+
+// Child 生成同一链路下的子 SpanContext
+func (sc SpanContext) Child() SpanContext {
+	child := sc
+	child.SpanID = randomHex(8)
+	return child
+}
+
+// Transport 为出站请求注入 traceparent/tracestate 的 http.RoundTripper
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// NewTransport 包装 base，base 为 nil 时使用 http.DefaultTransport
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sc, ok := SpanFromContext(req.Context())
+	if !ok {
+		return t.Base.RoundTrip(req)
+	}
+
+	// RoundTripper 不得修改原始请求
+	out := req.Clone(req.Context())
+	out.Header.Set(HeaderTraceparent, sc.Child().Traceparent())
+	if sc.TraceState != "" {
+		out.Header.Set(HeaderTracestate, sc.TraceState)
+	}
+	return t.Base.RoundTrip(out)
+}
+
+// randomHex 生成 n 字节的随机十六进制字符串
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}